@@ -0,0 +1,198 @@
+package comparator
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//arrayKey pairs elements of arrays matching the path by the value of the key member.
+type arrayKey struct {
+	path jsonPath
+	key  string
+}
+
+//keyArrays replaces arrays with keys set by WithArrayKeys with objects having the elements as members named by
+//the element keys, so that elements are diffed with their counterparts and addressed by the key, like /items/42.
+//Arrays with elements lacking the key or with duplicate keys are left intact.
+func (c *Comparator) keyArrays(path []string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			v[name] = c.keyArrays(childPath(path, name), child)
+		}
+	case []interface{}:
+		key, keyed := c.arrayKeyFor(path)
+		object := make(map[string]interface{}, len(v))
+		for _, element := range v {
+			id, ok := elementKey(element, key)
+			if _, duplicate := object[id]; !ok || duplicate {
+				keyed = false
+				break
+			}
+			object[id] = element
+		}
+		if keyed {
+			for id, element := range object {
+				object[id] = c.keyArrays(childPath(path, id), element)
+			}
+			return object
+		}
+		for i, element := range v {
+			v[i] = c.keyArrays(childPath(path, strconv.Itoa(i)), element)
+		}
+	}
+	return value
+}
+
+//alignJSON returns the b value with the parts that are semantically equal to the a value replaced by the a
+//parts, so that the differ doesn't report them. Numbers within the tolerance and, if coercion is enabled, numeric
+//strings equal to numbers are considered equal. Elements of unordered arrays are reordered to follow the order of
+//the equal a elements.
+func (c *Comparator) alignJSON(aValue, bValue interface{}) interface{} {
+	switch a := aValue.(type) {
+	case map[string]interface{}:
+		if b, ok := bValue.(map[string]interface{}); ok {
+			for name, bChild := range b {
+				if aChild, ok := a[name]; ok {
+					b[name] = c.alignJSON(aChild, bChild)
+				}
+			}
+		}
+		return bValue
+	case []interface{}:
+		b, ok := bValue.([]interface{})
+		if !ok {
+			return bValue
+		}
+		if c.unorderedArrays {
+			b = c.reorderByValue(a, b)
+		}
+		for i := 0; i < len(a) && i < len(b); i++ {
+			b[i] = c.alignJSON(a[i], b[i])
+		}
+		return b
+	}
+	if c.equalScalars(aValue, bValue) {
+		return aValue
+	}
+	return bValue
+}
+
+func childPath(path []string, token string) []string {
+	return append(path[:len(path):len(path)], token)
+}
+
+func (c *Comparator) arrayKeyFor(path []string) (string, bool) {
+	for _, arrayKey := range c.arrayKeys {
+		if arrayKey.path.match(path) {
+			return arrayKey.key, true
+		}
+	}
+	return "", false
+}
+
+//reorderByValue returns b elements in the order of the equal a elements, so that arrays are compared as
+//multisets. Elements without an equal counterpart follow the paired ones in their original order.
+func (c *Comparator) reorderByValue(a, b []interface{}) []interface{} {
+	used := make([]bool, len(b))
+	result := make([]interface{}, 0, len(b))
+	for _, element := range a {
+		for i, candidate := range b {
+			if !used[i] && c.equalJSON(element, candidate) {
+				used[i] = true
+				result = append(result, candidate)
+				break
+			}
+		}
+	}
+	return appendUnused(result, b, used)
+}
+
+func appendUnused(result, b []interface{}, used []bool) []interface{} {
+	for i, element := range b {
+		if !used[i] {
+			result = append(result, element)
+		}
+	}
+	return result
+}
+
+//elementKey returns the key member of an object element. Strings are used as they are, other values are
+//rendered as json text.
+func elementKey(element interface{}, key string) (string, bool) {
+	object, ok := element.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	value, ok := object[key]
+	if !ok {
+		return "", false
+	}
+	if text, ok := value.(string); ok {
+		return text, true
+	}
+	text, err := json.Marshal(value)
+	return string(text), err == nil
+}
+
+//equalJSON compares values deeply the same way alignJSON does without modifying them.
+func (c *Comparator) equalJSON(aValue, bValue interface{}) bool {
+	switch a := aValue.(type) {
+	case map[string]interface{}:
+		b, ok := bValue.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, aChild := range a {
+			bChild, ok := b[name]
+			if !ok || !c.equalJSON(aChild, bChild) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := bValue.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		if c.unorderedArrays {
+			b = c.reorderByValue(a, b)
+		}
+		return c.equalJSONArrays(a, b)
+	}
+	return aValue == bValue || c.equalScalars(aValue, bValue)
+}
+
+func (c *Comparator) equalJSONArrays(a, b []interface{}) bool {
+	for i := range a {
+		if !c.equalJSON(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Comparator) equalScalars(aValue, bValue interface{}) bool {
+	aNumber, aOK := c.jsonNumber(aValue)
+	bNumber, bOK := c.jsonNumber(bValue)
+	if !aOK || !bOK {
+		return false
+	}
+	return aNumber == bNumber || math.Abs(aNumber-bNumber) <= c.numericTolerance
+}
+
+//jsonNumber returns the numeric value of a json number or, if coercion is enabled, of a numeric string.
+func (c *Comparator) jsonNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		if c.coerceTypes {
+			number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			return number, err == nil
+		}
+	}
+	return 0, false
+}
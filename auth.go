@@ -0,0 +1,80 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+//Authenticator authenticates requests of a side, for example by adding credentials to the headers. It is called
+//for every attempt of every request and must be safe for concurrent use.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+//AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(req *http.Request) error
+
+//Authenticate calls f(req).
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+//BasicAuth authenticates requests with the user name and the password.
+func BasicAuth(username, password string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	})
+}
+
+//BearerToken authenticates requests with the static bearer token.
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+//APIKey authenticates requests with the key sent in the header, like X-API-Key.
+func APIKey(header, key string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set(header, key)
+		return nil
+	})
+}
+
+//oauth2Authenticator authenticates requests with tokens of the token source. The source is created on the first
+//request, so that tokens are cached and refreshed once they expire.
+type oauth2Authenticator struct {
+	config *clientcredentials.Config
+	once   sync.Once
+	source oauth2.TokenSource
+}
+
+//OAuth2ClientCredentials authenticates requests with bearer tokens obtained from the token url by the client
+//credentials grant. Tokens are cached until they expire.
+func OAuth2ClientCredentials(tokenURL, clientID, clientSecret string, scopes ...string) Authenticator {
+	return &oauth2Authenticator{config: &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       scopes,
+	}}
+}
+
+func (a *oauth2Authenticator) Authenticate(req *http.Request) error {
+	a.once.Do(func() {
+		a.source = a.config.TokenSource(context.Background())
+	})
+	token, err := a.source.Token()
+	if err != nil {
+		return fmt.Errorf("oauth2 token %s: %w", a.config.TokenURL, err)
+	}
+	token.SetAuthHeader(req)
+	return nil
+}
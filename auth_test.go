@@ -0,0 +1,31 @@
+package comparator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFailingAuthenticator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1}`)
+	}))
+	defer server.Close()
+	errNoCredentials := errors.New("no credentials")
+	failing := AuthenticatorFunc(func(*http.Request) error { return errNoCredentials })
+	c := New(WithSideRequests(Request{}, Request{Auth: failing}))
+
+	result, err := c.CompareResult(server.URL, server.URL, nil)
+	if err != nil {
+		t.Fatalf("CompareResult returned error %v", err)
+	}
+	errs := result.FetchErrors()
+	if len(errs) != 1 || errs[0].Side != SideB || !errors.Is(errs[0], errNoCredentials) {
+		t.Fatalf("unexpected fetch errors %v", errs)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].New != "no credentials" {
+		t.Errorf("unexpected changes %v", result.Changes)
+	}
+}
@@ -0,0 +1,89 @@
+package comparator
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+//defaultBatchWorkers is the number of concurrent comparisons of a batch unless configured otherwise.
+const defaultBatchWorkers = 8
+
+//Pair is a pair of urls compared in a batch. Elements are the compared html elements, nil compares the responses
+//according to their content type.
+type Pair struct {
+	AURL     string
+	BURL     string
+	Elements []string
+}
+
+//BatchResult is the result of the comparison of a pair of a batch.
+type BatchResult struct {
+	Pair   Pair
+	Result *Result
+	Err    error
+}
+
+//PathPairs pairs the paths resolved against both base urls.
+func PathPairs(aBase, bBase string, paths []string) []Pair {
+	pairs := make([]Pair, len(paths))
+	for i, path := range paths {
+		pairs[i] = Pair{AURL: joinURL(aBase, path), BURL: joinURL(bBase, path)}
+	}
+	return pairs
+}
+
+func joinURL(base, path string) string {
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+//CompareBatch compares the pairs concurrently with the default options.
+func CompareBatch(ctx context.Context, pairs []Pair) []BatchResult {
+	return defaultComparator.CompareBatch(ctx, pairs)
+}
+
+//CompareBatch compares the pairs concurrently by the configured number of workers. Results are returned in the
+//order of the pairs, pairs not compared before the context is done get the context error.
+func (c *Comparator) CompareBatch(ctx context.Context, pairs []Pair) []BatchResult {
+	results := make([]BatchResult, len(pairs))
+	c.forEach(ctx, len(pairs), func(i int) {
+		pair := pairs[i]
+		result, err := c.CompareResultContext(ctx, pair.AURL, pair.BURL, pair.Elements)
+		results[i] = BatchResult{pair, result, err}
+	}, func(i int) {
+		results[i] = BatchResult{Pair: pairs[i], Err: ctx.Err()}
+	})
+	return results
+}
+
+//forEach calls do for the indexes from zero to n concurrently by the configured number of workers. Indexes not
+//reached before the context is done are passed to skip instead.
+func (c *Comparator) forEach(ctx context.Context, n int, do, skip func(i int)) {
+	workers := c.batchWorkers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	if workers > n {
+		workers = n
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				do(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if ctx.Err() != nil {
+			skip(i)
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,106 @@
+package comparator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	//byteRangeGap is the number of equal bytes that separates differing byte ranges.
+	byteRangeGap = 8
+	//maxByteRanges limits the number of reported byte ranges, the rest of the bodies is reported as one range.
+	maxByteRanges = 16
+	//hexPreviewLength is the maximum number of bytes shown for a range.
+	hexPreviewLength = 16
+)
+
+//byteRange is a range of differing bytes, end is exclusive and may exceed the length of the shorter body.
+type byteRange struct {
+	start, end int
+}
+
+//compareBinaries compares the bodies byte by byte. Sizes and sha256 hashes of the bodies are reported along
+//with the differing byte ranges, changes of the ranges are located at "body/bytes/<offset>" and hold hex
+//previews of the bytes.
+func compareBinaries(aBody, bBody []byte) *Result {
+	result := &Result{}
+	if bytes.Equal(aBody, bBody) {
+		return result
+	}
+	if len(aBody) != len(bBody) {
+		result.add(Change{"body/size", Modified, len(aBody), len(bBody)},
+			Diff{fmt.Sprintf("Size: %d bytes", len(aBody)), Delete},
+			Diff{fmt.Sprintf("Size: %d bytes", len(bBody)), Insert})
+	}
+	aHash := sha256.Sum256(aBody)
+	bHash := sha256.Sum256(bBody)
+	result.add(Change{"body/sha256", Modified, hex.EncodeToString(aHash[:]), hex.EncodeToString(bHash[:])},
+		Diff{"SHA-256: " + hex.EncodeToString(aHash[:]), Delete},
+		Diff{"SHA-256: " + hex.EncodeToString(bHash[:]), Insert})
+	for _, r := range differingRanges(aBody, bBody) {
+		aPreview, aOK := hexPreview(aBody, r)
+		bPreview, bOK := hexPreview(bBody, r)
+		change := Change{Path: fmt.Sprintf("body/bytes/%d", r.start), Kind: changeKind(aOK, bOK)}
+		var diffs []Diff
+		label := fmt.Sprintf("Bytes %#x-%#x: ", r.start, r.end)
+		if aOK {
+			change.Old = aPreview
+			diffs = append(diffs, Diff{label + aPreview, Delete})
+		}
+		if bOK {
+			change.New = bPreview
+			diffs = append(diffs, Diff{label + bPreview, Insert})
+		}
+		result.add(change, diffs...)
+	}
+	return result
+}
+
+//differingRanges finds ranges of differing bytes merging the ones separated by less than byteRangeGap equal
+//bytes. The tail of the longer body is a differing range too.
+func differingRanges(a, b []byte) []byteRange {
+	common := len(a)
+	if len(b) < common {
+		common = len(b)
+	}
+	longest := len(a) + len(b) - common
+	var ranges []byteRange
+	for i := 0; i < longest; i++ {
+		if i < common && a[i] == b[i] {
+			continue
+		}
+		if len(ranges) == maxByteRanges {
+			ranges[len(ranges)-1].end = longest
+			break
+		}
+		if last := len(ranges) - 1; last >= 0 && i-ranges[last].end < byteRangeGap {
+			ranges[last].end = i + 1
+			continue
+		}
+		ranges = append(ranges, byteRange{i, i + 1})
+	}
+	return ranges
+}
+
+//hexPreview renders the bytes of the range in hex, at most hexPreviewLength of them. It reports false if the
+//body ends before the range.
+func hexPreview(body []byte, r byteRange) (string, bool) {
+	if r.start >= len(body) {
+		return "", false
+	}
+	end := r.end
+	if end > len(body) {
+		end = len(body)
+	}
+	truncated := end-r.start > hexPreviewLength
+	if truncated {
+		end = r.start + hexPreviewLength
+	}
+	preview := fmt.Sprintf("% x", body[r.start:end])
+	if truncated {
+		preview += " ..."
+	}
+	return preview, true
+}
@@ -0,0 +1,138 @@
+package comparator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+)
+
+//BodySizePolicy tells how the bodies larger than the maximal body size are compared.
+type BodySizePolicy int8
+
+//Body size policies.
+const (
+	//BodySizeFail fails the comparison with BodySizeError.
+	BodySizeFail BodySizePolicy = iota
+	//BodySizeTruncate compares the beginnings of the bodies up to the size and marks the result as truncated.
+	BodySizeTruncate
+	//BodySizeHash compares only the sizes and sha256 hashes of the whole bodies, which are read without being kept
+	//in memory.
+	BodySizeHash
+)
+
+//errBodyHashed stops reading of the body exceeding the maximal size once it is hashed.
+var errBodyHashed = errors.New("body hashed")
+
+//limitedBody enforces the maximal body size by the policy. The hashing policy hashes everything read and reads the
+//rest of the body once it exceeds the size or is closed.
+type limitedBody struct {
+	io.ReadCloser
+	err      *BodySizeError
+	policy   BodySizePolicy
+	read     int64
+	hash     hash.Hash
+	exceeded bool
+	done     bool
+	//drainErr is the error of reading the rest of the body to be hashed.
+	drainErr error
+}
+
+//limitBody wraps the body of the response if the maximal body size is set.
+func (c *Comparator) limitBody(resp *http.Response, side Side) *limitedBody {
+	if c.maxBodySize <= 0 {
+		return nil
+	}
+	body := &limitedBody{ReadCloser: resp.Body, err: &BodySizeError{side, responseURL(resp), c.maxBodySize},
+		policy: c.bodySizePolicy}
+	if body.policy == BodySizeHash {
+		body.hash = sha256.New()
+	}
+	resp.Body = body
+	return body
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, b.exceededErr()
+	}
+	if remaining := b.err.Limit + 1 - b.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.hash != nil {
+		b.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.done = true
+	}
+	if b.read <= b.err.Limit {
+		return n, err
+	}
+	b.exceeded = true
+	n -= int(b.read - b.err.Limit)
+	if b.hash != nil {
+		if err := b.drain(); err != nil {
+			return 0, err
+		}
+	}
+	return n, b.exceededErr()
+}
+
+//exceededErr is returned by reads of the body exceeding the size.
+func (b *limitedBody) exceededErr() error {
+	switch b.policy {
+	case BodySizeTruncate:
+		return io.EOF
+	case BodySizeHash:
+		return errBodyHashed
+	}
+	return b.err
+}
+
+//drain hashes the rest of the body.
+func (b *limitedBody) drain() error {
+	if b.done {
+		return nil
+	}
+	n, err := io.Copy(b.hash, b.ReadCloser)
+	b.read += n
+	if err != nil {
+		b.drainErr = err
+		return err
+	}
+	b.done = true
+	return nil
+}
+
+//Close hashes the rest of the body first by the hashing policy, so the hashes of both sides are complete whichever
+//side exceeded the size.
+func (b *limitedBody) Close() error {
+	if b.hash != nil {
+		b.drain()
+	}
+	return b.ReadCloser.Close()
+}
+
+//truncated reports whether the body was compared truncated.
+func (b *limitedBody) truncated() bool {
+	return b != nil && b.exceeded && b.policy == BodySizeTruncate
+}
+
+//compareHashes compares the sizes and hashes of the bodies exceeding the maximal size by the hashing policy.
+func compareHashes(aResp, bResp *http.Response, aBody, bBody *limitedBody) (*Result, error) {
+	aResp.Body.Close()
+	bResp.Body.Close()
+	for _, body := range []*limitedBody{aBody, bBody} {
+		if body.drainErr != nil {
+			return nil, body.drainErr
+		}
+	}
+	result := &Result{}
+	addHashChanges(result, aBody.read, bBody.read, hex.EncodeToString(aBody.hash.Sum(nil)),
+		hex.EncodeToString(bBody.hash.Sum(nil)))
+	return result, nil
+}
@@ -0,0 +1,111 @@
+//Package boltstore records comparison runs in a bolt database file, so that their history can be queried by
+//comparator.History and comparator.Trend across processes:
+//
+//	store, err := boltstore.Open("runs.db")
+//	if err != nil {
+//		return err
+//	}
+//	defer store.Close()
+//	run, err := comparator.RecordSuite(ctx, store, comparator.RunSuite(ctx, suite))
+package boltstore
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Rozakh/comparator"
+	bolt "go.etcd.io/bbolt"
+)
+
+//runsBucket keeps the runs as json documents by their big endian ids.
+var runsBucket = []byte("runs")
+
+//Store is a comparator.Store backed by a bolt database. It is safe for concurrent use, but the database file can be
+//opened by a single process at a time.
+type Store struct {
+	db *bolt.DB
+}
+
+//Open opens the database file creating it if it doesn't exist. It waits at most a second for other processes to
+//close the file.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("boltstore: open %s: %v", path, err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(runsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("boltstore: open %s: %v", path, err)
+	}
+	return &Store{db}, nil
+}
+
+//Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+//SaveRun records the run assigning it the next id.
+func (s *Store) SaveRun(ctx context.Context, run *comparator.Run) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+		id, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		saved := *run
+		saved.ID = int64(id)
+		value, err := json.Marshal(&saved)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(key(id), value); err != nil {
+			return err
+		}
+		run.ID = saved.ID
+		return nil
+	})
+}
+
+//Runs returns the runs made at or after the time ordered by their times.
+func (s *Store) Runs(ctx context.Context, since time.Time) ([]*comparator.Run, error) {
+	var runs []*comparator.Run
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(runsBucket).ForEach(func(k, value []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var run comparator.Run
+			if err := json.Unmarshal(value, &run); err != nil {
+				return fmt.Errorf("boltstore: run %d: %v", binary.BigEndian.Uint64(k), err)
+			}
+			if !run.Time.Before(since) {
+				runs = append(runs, &run)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Time.Before(runs[j].Time)
+	})
+	return runs, nil
+}
+
+func key(id uint64) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, id)
+	return k
+}
@@ -0,0 +1,73 @@
+package boltstore
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rozakh/comparator"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "runs.db")
+	ctx := context.Background()
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range []*comparator.Run{
+		{Name: "second", Time: start.Add(2 * time.Hour)},
+		{Name: "first", Time: start.Add(time.Hour), Results: []comparator.RunResult{
+			{AURL: "http://a/users", BURL: "http://b/users", Changes: []comparator.Change{
+				{Path: "/id", Kind: comparator.Modified, Old: 1.0, New: 2.0},
+			}},
+		}},
+		{Name: "old", Time: start},
+	} {
+		if err := store.SaveRun(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+		if run.ID == 0 {
+			t.Errorf("run %s got no id", run.Name)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	runs, err := store.Runs(ctx, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Name != "first" || runs[1].Name != "second" {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+	if runs[0].ID != 2 || runs[1].ID != 1 {
+		t.Errorf("got ids %d and %d, want 2 and 1", runs[0].ID, runs[1].ID)
+	}
+	if results := runs[0].Results; len(results) != 1 || len(results[0].Changes) != 1 ||
+		results[0].Changes[0].Path != "/id" || results[0].Changes[0].New != 2.0 {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := store.SaveRun(cancelled, &comparator.Run{Name: "cancelled", Time: start}); err == nil {
+		t.Errorf("SaveRun saved with a cancelled context")
+	}
+}
@@ -0,0 +1,133 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//ResponseCache keeps fetched responses by their keys, so that a url requested by many comparisons, like a baseline
+//compared with many candidates, is fetched once. It must be safe for concurrent use.
+type ResponseCache interface {
+	//Get returns the response of the key if it is cached.
+	Get(key string) (*CachedResponse, bool)
+	//Set caches the response of the key.
+	Set(key string, response *CachedResponse)
+}
+
+//CachedResponse is a cached response with its decoded body. The response keeps the status, the headers, the tls
+//connection and the redirect chain, its body is not set.
+type CachedResponse struct {
+	Response *http.Response
+	Body     []byte
+}
+
+//response returns a copy of the cached response with its own headers and body.
+func (r *CachedResponse) response() *http.Response {
+	resp := *r.Response
+	resp.Header = r.Response.Header.Clone()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(r.Body))
+	resp.ContentLength = int64(len(r.Body))
+	return &resp
+}
+
+//cacheable reports whether the response of the request may be cached, only GET and HEAD requests without bodies
+//are cached.
+func cacheable(req *http.Request) bool {
+	return (req.Method == http.MethodGet || req.Method == http.MethodHead) && (req.Body == nil || req.Body == http.NoBody)
+}
+
+//cacheKey identifies the request by its method, url, host and headers, including the authentication and the
+//cookies of its side.
+func cacheKey(req *http.Request) string {
+	var key strings.Builder
+	key.WriteString(req.Method + " " + req.URL.String() + "\nHost: " + req.Host)
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key.WriteString("\n" + name + ": " + strings.Join(req.Header[name], ", "))
+	}
+	return key.String()
+}
+
+//fetchCached returns the cached response of the request or sends it and caches its response. Requests of sides with
+//different dial overrides or protocols are cached separately. Concurrent requests with the same key are sent once.
+//Server errors are not cached, so that they are retried.
+func (c *Comparator) fetchCached(ctx context.Context, req *http.Request, request *Request) (*http.Response,
+	error) {
+	key := cacheKey(req)
+	if transport := request.transportKey(); transport != "" {
+		key += "\nTransport: " + transport
+	}
+	if cached, ok := c.cache.Get(key); ok {
+		c.debug(ctx, "cached response", "method", req.Method, "url", req.URL.String())
+		return cached.response(), nil
+	}
+	value, err, _ := c.cacheFlight.Do(key, func() (interface{}, error) {
+		resp, err := c.send(ctx, req, request)
+		if err != nil {
+			return nil, err
+		}
+		body, err := readBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = nil
+		cached := &CachedResponse{resp, body}
+		if resp.StatusCode < 500 {
+			c.cache.Set(key, cached)
+		}
+		return cached, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*CachedResponse).response(), nil
+}
+
+//MemoryCache is a ResponseCache keeping the responses in memory for a time.
+type MemoryCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	response *CachedResponse
+	expires  time.Time
+}
+
+//NewMemoryCache creates a cache keeping the responses for the ttl, or for its whole life if the ttl is zero.
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{ttl: ttl, entries: make(map[string]memoryCacheEntry)}
+}
+
+//Get returns the response of the key unless it expired.
+func (m *MemoryCache) Get(key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if m.ttl > 0 && time.Now().After(entry.expires) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.response, true
+}
+
+//Set caches the response of the key for the ttl.
+func (m *MemoryCache) Set(key string, response *CachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryCacheEntry{response, time.Now().Add(m.ttl)}
+}
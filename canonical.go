@@ -0,0 +1,158 @@
+package comparator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+//CanonicalJSON renders the json document in the canonical form of RFC 8785, the JSON Canonicalization Scheme:
+//without whitespace, with object members sorted by the utf-16 code units of their names, strings escaped only where
+//json requires it and numbers written as the shortest double precision values, like 1000 for 1e3 and 1 for 1.0.
+//Numbers beyond the precision of doubles, like large integer ids, are rounded as the scheme prescribes.
+func CanonicalJSON(document []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("canonical json: trailing data after the document")
+	}
+	var buf bytes.Buffer
+	if err := writeCanonical(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//writeCanonical writes the value decoded with json numbers in the canonical form.
+func writeCanonical(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		number, err := canonicalNumber(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(number)
+	case string:
+		writeCanonicalString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeCanonical(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool { return lessUTF16(names[i], names[j]) })
+		buf.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, name)
+			buf.WriteByte(':')
+			if err := writeCanonical(buf, v[name]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("canonical json: unsupported value %T", value)
+	}
+	return nil
+}
+
+//canonicalNumber writes the number as ECMAScript does: integers and decimals in plain notation up to 21 digits, the
+//exponent notation like 1e+21 and 1e-7 otherwise.
+func canonicalNumber(number json.Number) (string, error) {
+	f, err := strconv.ParseFloat(string(number), 64)
+	if err != nil {
+		return "", fmt.Errorf("canonical json: number %s: %v", number, err)
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("canonical json: number %s out of range", number)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	}
+	formatted := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exponent := formatted[:strings.IndexByte(formatted, 'e')], formatted[strings.IndexByte(formatted, 'e')+1:]
+	sign := exponent[0]
+	exponent = strings.TrimLeft(exponent[1:], "0")
+	return mantissa + "e" + string(sign) + exponent, nil
+}
+
+//writeCanonicalString writes the quoted string escaping only the quotation mark, the reverse solidus and the
+//control characters, the ones with short escapes like \n by them.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+//lessUTF16 orders the strings by their utf-16 code units as the canonicalization scheme sorts member names.
+func lessUTF16(a, b string) bool {
+	for a != "" && b != "" {
+		aRune, aSize := utf8.DecodeRuneInString(a)
+		bRune, bSize := utf8.DecodeRuneInString(b)
+		if aRune != bRune {
+			aUnits, bUnits := utf16.Encode([]rune{aRune}), utf16.Encode([]rune{bRune})
+			for i := 0; i < len(aUnits) && i < len(bUnits); i++ {
+				if aUnits[i] != bUnits[i] {
+					return aUnits[i] < bUnits[i]
+				}
+			}
+			return len(aUnits) < len(bUnits)
+		}
+		a, b = a[aSize:], b[bSize:]
+	}
+	return a == "" && b != ""
+}
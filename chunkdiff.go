@@ -0,0 +1,154 @@
+package comparator
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+//defaultParallelDiffSize is the size of the texts above which they are diffed in parallel chunks by default.
+const defaultParallelDiffSize = 1 << 20
+
+//minChunkLines is the least number of lines of the chunks diffed in parallel.
+const minChunkLines = 256
+
+//chunkPair is the part of both texts diffed by one goroutine.
+type chunkPair struct {
+	a, b string
+}
+
+//diffChunked splits large texts on anchors into chunks, diffs the chunks in parallel by the diff function and
+//merges their diffs. Anchors are lines occurring exactly once in both texts and in the same order, found as the
+//longest increasing subsequence of their positions like patience diff does. Texts that can't be split, because they
+//have too few lines or no anchors, are diffed as a whole.
+func diffChunked(aText, bText string, diff func(aText, bText string) []diffmatchpatch.Diff) []diffmatchpatch.Diff {
+	chunks := splitChunks(splitLines(aText), splitLines(bText))
+	if len(chunks) < 2 {
+		return diff(aText, bText)
+	}
+	results := make([][]diffmatchpatch.Diff, len(chunks))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = diff(chunks[i].a, chunks[i].b)
+			}
+		}()
+	}
+	for i := range chunks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	var merged []diffmatchpatch.Diff
+	for _, diffs := range results {
+		for _, d := range diffs {
+			if d.Text == "" {
+				continue
+			}
+			if last := len(merged) - 1; last >= 0 && merged[last].Type == d.Type {
+				merged[last].Text += d.Text
+				continue
+			}
+			merged = append(merged, d)
+		}
+	}
+	return merged
+}
+
+//splitChunks partitions the lines of both texts into chunks starting at anchors, every chunk but the first one
+//starts with an anchor line equal on both sides. Chunks have at least minChunkLines lines and there are a few times
+//more chunks than processors, so that the goroutines stay busy.
+func splitChunks(aLines, bLines []string) []chunkPair {
+	lines := len(aLines)
+	if len(bLines) > lines {
+		lines = len(bLines)
+	}
+	chunkLines := lines / (4 * runtime.GOMAXPROCS(0))
+	if chunkLines < minChunkLines {
+		chunkLines = minChunkLines
+	}
+	if lines < 2*chunkLines {
+		return nil
+	}
+	var chunks []chunkPair
+	aStart, bStart := 0, 0
+	for _, anchor := range uniqueAnchors(aLines, bLines) {
+		if anchor[0]-aStart < chunkLines && anchor[1]-bStart < chunkLines {
+			continue
+		}
+		chunks = append(chunks, chunkPair{strings.Join(aLines[aStart:anchor[0]], ""),
+			strings.Join(bLines[bStart:anchor[1]], "")})
+		aStart, bStart = anchor[0], anchor[1]
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	return append(chunks, chunkPair{strings.Join(aLines[aStart:], ""), strings.Join(bLines[bStart:], "")})
+}
+
+//uniqueAnchors returns the positions of the lines occurring once in both texts that keep their order, as the longest
+//increasing subsequence of their positions in the b lines ordered by their positions in the a lines. Blank lines are
+//never anchors.
+func uniqueAnchors(aLines, bLines []string) [][2]int {
+	type occurrences struct{ a, b, aIndex, bIndex int }
+	counts := make(map[string]*occurrences)
+	for i, line := range aLines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		count := counts[line]
+		if count == nil {
+			count = &occurrences{}
+			counts[line] = count
+		}
+		count.a++
+		count.aIndex = i
+	}
+	for i, line := range bLines {
+		if count := counts[line]; count != nil {
+			count.b++
+			count.bIndex = i
+		}
+	}
+	var candidates [][2]int
+	for _, count := range counts {
+		if count.a == 1 && count.b == 1 {
+			candidates = append(candidates, [2]int{count.aIndex, count.bIndex})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i][0] < candidates[j][0] })
+	//tails[k] is the candidate ending the increasing subsequence of length k+1 with the least b position.
+	var tails []int
+	previous := make([]int, len(candidates))
+	for i, candidate := range candidates {
+		k := sort.Search(len(tails), func(k int) bool { return candidates[tails[k]][1] >= candidate[1] })
+		previous[i] = -1
+		if k > 0 {
+			previous[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	if len(tails) == 0 {
+		return nil
+	}
+	anchors := make([][2]int, len(tails))
+	for k, i := len(tails)-1, tails[len(tails)-1]; k >= 0; k, i = k-1, previous[i] {
+		anchors[k] = candidates[i]
+	}
+	return anchors
+}
@@ -0,0 +1,335 @@
+//Command comparator compares http responses of two urls or of the pairs of the suites of a config file:
+//
+//	comparator [flags] https://a.example.com/page https://b.example.com/page
+//	comparator [flags] --config suites.yaml
+//
+//Flags may follow the urls. The exit status is 0 if the responses are equal, 1 if they differ and 2 if they could
+//not be compared.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Rozakh/comparator"
+)
+
+//Exit statuses.
+const (
+	exitEqual     = 0
+	exitDifferent = 1
+	exitFailed    = 2
+)
+
+//listFlag collects the values of a repeated flag.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+//parseResolve parses the dial overrides given like host=address.
+func parseResolve(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	resolve := make(map[string]string, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid resolve %q, expected host=address", value)
+		}
+		resolve[parts[0]] = parts[1]
+	}
+	return resolve, nil
+}
+
+//pairResult is a result as written by the json format.
+type pairResult struct {
+	AURL    string              `json:"a_url"`
+	BURL    string              `json:"b_url"`
+	Equal   bool                `json:"equal"`
+	Changes []comparator.Change `json:"changes"`
+	Error   string              `json:"error,omitempty"`
+	//FetchErrors are the errors of the sides that could not be fetched.
+	FetchErrors []string `json:"fetch_errors,omitempty"`
+	Suite       string   `json:"suite,omitempty"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("comparator", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var selectors, ignored, headers, aResolve, bResolve listFlag
+	flags.Var(&selectors, "selector", "compare only the html elements selected by the css selector, or by the "+
+		"selectors of both sides like 'a => b', repeatable")
+	flags.Var(&ignored, "ignore", "ignore json values matching the JSONPath or JSON Pointer pattern, repeatable")
+	flags.Var(&headers, "header", "compare the response header, repeatable, all headers are compared by --headers")
+	format := flags.String("format", "text", "output format: text, terminal, unified, json or html")
+	sideBySide := flags.Bool("side-by-side", false, "render the terminal format in two columns")
+	modeName := flags.String("mode", "auto", "comparison mode: auto, json, html, xml, yaml, text, binary, image, "+
+		"ndjson, csv, protobuf, pdf, feed, links or schema")
+	config := flags.String("config", "", "json or yaml file of comparison suites, their options override the flags")
+	allHeaders := flags.Bool("headers", false, "compare response headers")
+	status := flags.Bool("status", false, "compare status codes")
+	cookies := flags.Bool("cookies", false, "compare cookies")
+	security := flags.Bool("security", false, "compare tls connections and security headers")
+	metadataOnly := flags.Bool("metadata-only", false, "compare only the status, headers and cookies")
+	timeout := flags.Duration("timeout", 0, "fetch timeout of every comparison")
+	workers := flags.Int("workers", 0, "number of concurrent comparisons of the config pairs")
+	polite := flags.Bool("polite", false, "limit the rate and concurrency of the requests to every host")
+	cache := flags.Bool("cache", false, "fetch every url of the config pairs once")
+	flags.Var(&aResolve, "a-resolve", "dial the host of the a side at the address, like api.example.com=10.0.0.5:443, "+
+		"repeatable")
+	flags.Var(&bResolve, "b-resolve", "dial the host of the b side at the address, repeatable")
+	aServerName := flags.String("a-sni", "", "tls server name sent by the a side")
+	bServerName := flags.String("b-sni", "", "tls server name sent by the b side")
+	aProtocol := flags.String("a-protocol", "auto", "http protocol of the a side: auto, http/1.1, h2 or h3")
+	bProtocol := flags.String("b-protocol", "auto", "http protocol of the b side: auto, http/1.1, h2 or h3")
+	protocols := flags.Bool("protocols", false, "compare the http protocols and trailers of the responses")
+	urls, err := parseInterspersed(flags, args)
+	if err != nil {
+		return exitFailed
+	}
+	switch *format {
+	case "text", "terminal", "unified", "json", "html":
+	default:
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return exitFailed
+	}
+	var mode comparator.Mode
+	if err := mode.UnmarshalText([]byte(*modeName)); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailed
+	}
+	options := []comparator.Option{comparator.WithMode(mode)}
+	if len(ignored) > 0 {
+		options = append(options, comparator.WithIgnoredPaths(ignored...))
+	}
+	if *allHeaders || len(headers) > 0 {
+		options = append(options, comparator.WithHeaderComparison(headers...))
+	}
+	if *status {
+		options = append(options, comparator.WithStatusComparison())
+	}
+	if *cookies {
+		options = append(options, comparator.WithCookieComparison())
+	}
+	if *security {
+		options = append(options, comparator.WithSecurityComparison())
+	}
+	if *metadataOnly {
+		options = append(options, comparator.WithMetadataOnly())
+	}
+	if *timeout > 0 {
+		options = append(options, comparator.WithFetchTimeout(*timeout))
+	}
+	if *workers > 0 {
+		options = append(options, comparator.WithBatchWorkers(*workers))
+	}
+	if *polite {
+		options = append(options, comparator.WithRateLimit(comparator.DefaultRateLimit))
+	}
+	if *cache {
+		options = append(options, comparator.WithResponseCache(comparator.NewMemoryCache(0)))
+	}
+	if *protocols {
+		options = append(options, comparator.WithProtocolComparison())
+	}
+	a := comparator.Request{ServerName: *aServerName}
+	b := comparator.Request{ServerName: *bServerName}
+	for _, err := range []error{a.Protocol.UnmarshalText([]byte(*aProtocol)),
+		b.Protocol.UnmarshalText([]byte(*bProtocol))} {
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+	}
+	if a.Resolve, err = parseResolve(aResolve); err == nil {
+		b.Resolve, err = parseResolve(bResolve)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailed
+	}
+	if a.Resolve != nil || b.Resolve != nil || a.ServerName != "" || b.ServerName != "" ||
+		a.Protocol != comparator.ProtocolAuto || b.Protocol != comparator.ProtocolAuto {
+		options = append(options, comparator.WithSideRequests(a, b))
+	}
+	var elements []string
+	if len(selectors) > 0 {
+		elements = selectors
+	}
+	suites, err := loadSuites(*config, urls, elements)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		flags.Usage()
+		return exitFailed
+	}
+	var results []comparator.BatchResult
+	var names []string
+	for _, suite := range comparator.RunSuites(context.Background(), suites, options...) {
+		if suite.Err != nil {
+			fmt.Fprintf(stderr, "suite %s: %v\n", suite.Name, suite.Err)
+			return exitFailed
+		}
+		results = append(results, suite.Results...)
+		for range suite.Results {
+			names = append(names, suite.Name)
+		}
+	}
+	if err := write(stdout, *format, results, names, comparator.TerminalOptions{SideBySide: *sideBySide}); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailed
+	}
+	code := exitEqual
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Fprintf(stderr, "%s vs %s: %v\n", result.Pair.AURL, result.Pair.BURL, result.Err)
+			code = exitFailed
+		} else if len(result.Result.FetchErrors()) > 0 {
+			for _, err := range result.Result.FetchErrors() {
+				fmt.Fprintf(stderr, "%s vs %s: %v\n", result.Pair.AURL, result.Pair.BURL, err)
+			}
+			code = exitFailed
+		} else if !result.Result.Equal() && code == exitEqual {
+			code = exitDifferent
+		}
+	}
+	return code
+}
+
+//parseInterspersed parses the flags that may be mixed with the positional arguments, which are returned.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		if flags.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+//loadSuites reads the suites of the config file or makes a suite of the urls of the command line.
+func loadSuites(config string, urls, elements []string) ([]comparator.Suite, error) {
+	if config == "" {
+		if len(urls) != 2 {
+			return nil, errors.New("two urls or a config file are required")
+		}
+		return []comparator.Suite{{Pairs: []comparator.SuitePair{{A: urls[0], B: urls[1], Elements: elements}}}}, nil
+	}
+	if len(urls) > 0 {
+		return nil, errors.New("urls and a config file are mutually exclusive")
+	}
+	suites, err := comparator.LoadSuites(config)
+	if err != nil {
+		return nil, err
+	}
+	for i := range suites {
+		if suites[i].Elements == nil {
+			suites[i].Elements = elements
+		}
+	}
+	return suites, nil
+}
+
+//write renders the results in the format, results of several pairs are preceded by the compared urls. Names are
+//the suites of the results. The terminal format is rendered by the options.
+func write(w io.Writer, format string, results []comparator.BatchResult, names []string,
+	options comparator.TerminalOptions) error {
+	if format == "json" {
+		return writeJSON(w, results, names)
+	}
+	for _, result := range results {
+		if result.Result == nil {
+			continue
+		}
+		if len(results) > 1 && format != "html" {
+			fmt.Fprintf(w, "=== %s vs %s\n", result.Pair.AURL, result.Pair.BURL)
+		}
+		var err error
+		switch format {
+		case "text":
+			err = writeText(w, result.Result)
+		case "terminal":
+			err = result.Result.WriteTerminal(w, options)
+		case "unified":
+			err = result.Result.WriteUnified(w)
+		default:
+			err = result.Result.WriteHTML(w)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//writeText writes the fetch errors and the changes one per line.
+func writeText(w io.Writer, result *comparator.Result) error {
+	for _, fetchErr := range result.FetchErrors() {
+		if _, err := fmt.Fprintf(w, "error %s: %v\n", fetchErr.Side, fetchErr.Err); err != nil {
+			return err
+		}
+	}
+	for _, change := range result.Changes {
+		var err error
+		switch change.Kind {
+		case comparator.Added:
+			_, err = fmt.Fprintf(w, "%s %s: %v\n", change.Kind, change.Path, change.New)
+		case comparator.Removed:
+			_, err = fmt.Fprintf(w, "%s %s: %v\n", change.Kind, change.Path, change.Old)
+		default:
+			_, err = fmt.Fprintf(w, "%s %s: %v -> %v\n", change.Kind, change.Path, change.Old, change.New)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//writeJSON writes a single result as an object and several results as an array.
+func writeJSON(w io.Writer, results []comparator.BatchResult, names []string) error {
+	written := make([]pairResult, len(results))
+	for i, result := range results {
+		written[i] = pairResult{AURL: result.Pair.AURL, BURL: result.Pair.BURL, Changes: []comparator.Change{},
+			Suite: names[i]}
+		if result.Result != nil {
+			written[i].Equal = result.Result.Equal()
+			if result.Result.Changes != nil {
+				written[i].Changes = result.Result.Changes
+			}
+			for _, err := range result.Result.FetchErrors() {
+				written[i].FetchErrors = append(written[i].FetchErrors, err.Error())
+			}
+		}
+		if result.Err != nil {
+			written[i].Error = result.Err.Error()
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if len(written) == 1 {
+		return encoder.Encode(written[0])
+	}
+	return encoder.Encode(written)
+}
@@ -1,27 +1,34 @@
 package comparator
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/yudai/gojsondiff"
-	"github.com/yudai/gojsondiff/formatter"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 //Diff type constants.
 const (
 	Delete DiffType = -1
+	Equal  DiffType = 0
 	Insert DiffType = 1
+	Move   DiffType = 2
 )
 
 var (
-	jsonDiffer *gojsondiff.Differ
-	textDiffer *diffmatchpatch.DiffMatchPatch
+	jsonDiffer        *gojsondiff.Differ
+	textDiffer        *diffmatchpatch.DiffMatchPatch
+	defaultComparator *Comparator
 )
 
 //Diff includes text difference and diff type.
@@ -30,114 +37,506 @@ type Diff struct {
 	Type DiffType
 }
 
-//DiffType is a type of the difference(insert or delete).
+//DiffType is a type of the difference(insert, delete or move) or of the unchanged text around it.
 type DiffType int8
 
+//Comparator compares http responses according to its options. Use New to create one. A Comparator is safe for
+//concurrent use and should be reused for comparisons with the same options: the JSONPath patterns of its options are
+//compiled once by New, regexes are passed compiled, and the css selectors and xpath expressions of the compared
+//elements are compiled on their first use and cached.
+type Comparator struct {
+	client           *http.Client
+	aRequest         Request
+	bRequest         Request
+	retry            RetryPolicy
+	maxRedirects     int
+	detectMoves      bool
+	mode             Mode
+	contentTypeModes map[string]Mode
+	visibleTextOnly  bool
+	normalizations   Normalization
+	granularity      Granularity
+	parallelDiffSize int
+	htmlStructure    bool
+	xpathSelectors   bool
+	latencyBudget    time.Duration
+	performance      PerformanceThresholds
+	fetchTimeout     time.Duration
+	maxInMemory      int64
+	rawBodies        bool
+	renderer         Renderer
+	pdfMetadata      bool
+	linkChecks       bool
+	trackingParams   []string
+	maxBodySize      int64
+	bodySizePolicy   BodySizePolicy
+	failFast         bool
+	batchWorkers     int
+	hostLimits       *hostLimits
+	cache            ResponseCache
+	cacheFlight      *singleflight.Group
+	compareStatus    bool
+	compareHeaders   bool
+	compareRedirects bool
+	headers          []string
+	ignoredHeaders   map[string]bool
+	metadataOnly     bool
+	compareCookies   bool
+	compareSecurity  bool
+	compareProtocol  bool
+	cookieValues     map[string]bool
+	hostReplacer     *strings.Replacer
+	ignoredPaths     []jsonPath
+	numericTolerance float64
+	similarities     []similarityThreshold
+	coerceTypes      bool
+	unorderedArrays  bool
+	arrayKeys        []arrayKey
+	recordKey        string
+	ignoredColumns   map[string]bool
+	protoMessage     protoreflect.MessageDescriptor
+	imageDiff        io.Writer
+	requestHooks     []RequestHook
+	responseHooks    []ResponseHook
+	diffHooks        []DiffHook
+	bodyNormalizers  []Normalizer
+	canonicalJSON    bool
+	masks            []scopedMask
+	metrics          *Metrics
+	logger           *slog.Logger
+	severityRules    []SeverityRule
+	equalSegments    bool
+	contextLines     int
+	selectors        selectorCache
+	//transports are the transports of the dial overrides of the sides by their keys.
+	transports sync.Map
+	//err is the first error of the options, it is returned by every comparison.
+	err error
+}
+
 func init() {
 	jsonDiffer = gojsondiff.New()
 	textDiffer = diffmatchpatch.New()
+	defaultComparator = New()
 }
 
-//Compare responses for the provided urls. Compare only specified html elements or compare responses as json if
-//elements are not provided.
+//New creates a Comparator configured with the provided options.
+func New(options ...Option) *Comparator {
+	c := &Comparator{client: http.DefaultClient, maxRedirects: -1}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+//Compare responses for the provided urls using the default options. Compare only specified html elements or
+//compare responses according to their content type if elements are not provided.
 func Compare(aURL, bURL string, compareElements []string) ([]Diff, error) {
-	aResp, aErr := http.Get(aURL)
-	bResp, bErr := http.Get(bURL)
+	return defaultComparator.Compare(aURL, bURL, compareElements)
+}
+
+//CompareContext is like Compare but fetches the responses with the provided context and stops the comparison
+//once the context is done.
+func CompareContext(ctx context.Context, aURL, bURL string, compareElements []string) ([]Diff, error) {
+	return defaultComparator.CompareContext(ctx, aURL, bURL, compareElements)
+}
+
+//CompareResult is like Compare but returns the structured result.
+func CompareResult(aURL, bURL string, compareElements []string) (*Result, error) {
+	return defaultComparator.CompareResult(aURL, bURL, compareElements)
+}
+
+//CompareResultContext is like CompareContext but returns the structured result.
+func CompareResultContext(ctx context.Context, aURL, bURL string, compareElements []string) (*Result, error) {
+	return defaultComparator.CompareResultContext(ctx, aURL, bURL, compareElements)
+}
+
+//Compare responses for the provided urls. Compare only specified html elements or compare responses according to
+//their content type if elements are not provided. Whole documents are compared for html responses, json, xml and
+//yaml documents are compared structurally, images pixel by pixel, text and binary bodies as a whole. If the
+//latency budget is exceeded the diffs are returned along with a *LatencyBudgetError unless fail fast is enabled.
+func (c *Comparator) Compare(aURL, bURL string, compareElements []string) ([]Diff, error) {
+	return c.CompareContext(context.Background(), aURL, bURL, compareElements)
+}
+
+//CompareContext is like Compare but fetches the responses with the provided context and stops the comparison
+//once the context is done. The context error is returned instead of being reported as a fetch diff.
+func (c *Comparator) CompareContext(ctx context.Context, aURL, bURL string, compareElements []string) ([]Diff,
+	error) {
+	return diffsOf(c.CompareResultContext(ctx, aURL, bURL, compareElements))
+}
+
+//CompareResult is like Compare but returns the structured result.
+func (c *Comparator) CompareResult(aURL, bURL string, compareElements []string) (*Result, error) {
+	return c.CompareResultContext(context.Background(), aURL, bURL, compareElements)
+}
+
+//CompareResultContext is like CompareContext but returns the structured result.
+func (c *Comparator) CompareResultContext(ctx context.Context, aURL, bURL string, compareElements []string) (
+	*Result, error) {
+	result, _, _, err := c.compareURLs(ctx, aURL, bURL, compareElements)
+	return result, err
+}
+
+//exchange describes fetching of the response of one side.
+type exchange struct {
+	url    string
+	status int
+	//ttfb is the time until the response headers are received, latency includes reading of the body.
+	ttfb    time.Duration
+	latency time.Duration
+	size    int64
+	body    *meteredBody
+	err     error
+}
+
+//compareURLs fetches the responses concurrently and compares them describing the exchanges of both sides along with
+//the result. Fetch errors, including the exceeded fetch timeout, are reported as differences of the sides by the
+//messages of their causes, their FetchErrors are kept by the result. The comparison is counted by the metrics if
+//they are configured.
+func (c *Comparator) compareURLs(ctx context.Context, aURL, bURL string, compareElements []string) (*Result,
+	exchange, exchange, error) {
+	c.debug(ctx, "comparing urls", "a_url", aURL, "b_url", bURL)
+	result, aExchange, bExchange, err := c.compareExchanges(ctx, aURL, bURL, compareElements)
+	if err != nil {
+		c.debug(ctx, "comparison failed", "a_url", aURL, "b_url", bURL, "error", err)
+	} else {
+		c.debug(ctx, "compared urls", "a_url", aURL, "b_url", bURL, "changes", len(result.Changes))
+	}
+	if c.metrics != nil && c.err == nil {
+		c.metrics.record(result, &aExchange, &bExchange, err)
+	}
+	return result, aExchange, bExchange, err
+}
+
+func (c *Comparator) compareExchanges(ctx context.Context, aURL, bURL string, compareElements []string) (*Result,
+	exchange, exchange, error) {
+	aExchange := exchange{url: aURL}
+	bExchange := exchange{url: bURL}
+	if c.err != nil {
+		return nil, aExchange, bExchange, c.err
+	}
+	fetchCtx := ctx
+	if c.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, c.fetchTimeout)
+		defer cancel()
+	}
+	var aResp, bResp *http.Response
+	var aErr, bErr error
+	var group errgroup.Group
+	group.Go(func() error {
+		start := time.Now()
+		aResp, aErr = c.get(fetchCtx, aURL, &c.aRequest)
+		aExchange.ttfb = time.Since(start)
+		return nil
+	})
+	group.Go(func() error {
+		start := time.Now()
+		bResp, bErr = c.get(fetchCtx, bURL, &c.bRequest)
+		bExchange.ttfb = time.Since(start)
+		return nil
+	})
+	group.Wait()
+	aExchange.describe(aResp, aErr)
+	bExchange.describe(bResp, bErr)
+	if err := ctx.Err(); err != nil {
+		closeBody(aResp)
+		closeBody(bResp)
+		return nil, aExchange, bExchange, err
+	}
+	budgetErr := c.checkLatencyBudget(aExchange.ttfb, bExchange.ttfb)
+	if budgetErr != nil && c.failFast {
+		closeBody(aResp)
+		closeBody(bResp)
+		return nil, aExchange, bExchange, budgetErr
+	}
+	result, err := c.compareResponses(ctx, aResp, aErr, bResp, bErr, compareElements)
+	aExchange.finish()
+	bExchange.finish()
+	if err != nil {
+		return nil, aExchange, bExchange, err
+	}
+	if aErr == nil && bErr == nil {
+		result.merge(c.comparePerformance(&aExchange, &bExchange))
+	}
+	c.postprocess(result)
+	result.fetchErrors = fetchErrors(aURL, aErr, bURL, bErr)
+	if budgetErr != nil {
+		return result, aExchange, bExchange, budgetErr
+	}
+	return result, aExchange, bExchange, nil
+}
+
+//describe records the outcome of the fetch and meters reading of the body.
+func (e *exchange) describe(resp *http.Response, err error) {
+	e.latency = e.ttfb
+	if err != nil {
+		e.err = err
+		return
+	}
+	e.status = resp.StatusCode
+	e.body = &meteredBody{ReadCloser: resp.Body}
+	resp.Body = e.body
+}
+
+//finish adds reading of the body to the latency.
+func (e *exchange) finish() {
+	if e.body != nil {
+		e.size = e.body.size
+		e.latency = e.ttfb + e.body.reading
+	}
+}
+
+//responseURL returns the url of the last request of the response.
+func responseURL(resp *http.Response) string {
+	if resp.Request == nil {
+		return ""
+	}
+	return resp.Request.URL.String()
+}
+
+//diffsOf renders the result of a comparison in the flat form keeping the error returned along with it.
+func diffsOf(result *Result, err error) ([]Diff, error) {
+	if result == nil {
+		return nil, err
+	}
+	return result.Diffs(), err
+}
+
+func (c *Comparator) compareResponses(ctx context.Context, aResp *http.Response, aErr error,
+	bResp *http.Response, bErr error, compareElements []string) (*Result, error) {
+	result := &Result{}
 	if aErr != nil && bErr == nil {
-		err := trimErrorHost(aErr)
-		return []Diff{Diff{err.Error(), Delete}, Diff{bResp.Status, Insert}}, nil
+		bResp.Body.Close()
+		failure := fetchFailure(aErr)
+		result.add(Change{"status", Modified, failure, bResp.Status},
+			Diff{failure, Delete}, Diff{bResp.Status, Insert})
+		return result, nil
 	}
 	if aErr == nil && bErr != nil {
-		err := trimErrorHost(bErr)
-		return []Diff{Diff{aResp.Status, Delete}, Diff{err.Error(), Insert}}, nil
+		aResp.Body.Close()
+		failure := fetchFailure(bErr)
+		result.add(Change{"status", Modified, aResp.Status, failure},
+			Diff{aResp.Status, Delete}, Diff{failure, Insert})
+		return result, nil
 	}
 	if aErr != nil && bErr != nil {
-		aError := trimErrorHost(aErr)
-		bError := trimErrorHost(bErr)
-		return compareStrings(aError.Error(), bError.Error()), nil
+		aFailure, bFailure := fetchFailure(aErr), fetchFailure(bErr)
+		if aFailure != bFailure {
+			result.add(Change{"status", Modified, aFailure, bFailure}, c.compareText(aFailure, bFailure)...)
+		}
+		return result, nil
 	}
-	if compareElements == nil {
-		return compareJSONs(aResp, bResp)
+	if c.compareStatus {
+		result.merge(c.traced(ctx, "status", compareStatuses(aResp, bResp)))
 	}
-	return compareHTMLs(aResp, bResp, compareElements)
-}
-
-func compareJSONs(aResp, bResp *http.Response) ([]Diff, error) {
-	var aJSON map[string]interface{}
-	defer aResp.Body.Close()
-	defer bResp.Body.Close()
-	aBody, err := ioutil.ReadAll(aResp.Body)
+	if c.compareRedirects {
+		result.merge(c.traced(ctx, "redirects", c.compareRedirectChains(aResp, bResp)))
+	}
+	if c.compareHeaders {
+		result.merge(c.traced(ctx, "headers", c.headerDiffs(aResp, bResp)))
+	}
+	if c.compareCookies {
+		result.merge(c.traced(ctx, "cookies", c.cookieDiffs(aResp, bResp)))
+	}
+	if c.compareSecurity {
+		result.merge(c.traced(ctx, "security", c.securityDiffs(aResp, bResp)))
+	}
+	if c.compareProtocol {
+		result.merge(c.traced(ctx, "protocols", protocolDiffs(aResp, bResp)))
+	}
+	if c.metadataOnly {
+		aResp.Body.Close()
+		bResp.Body.Close()
+		return result, nil
+	}
+	bodies, err := c.compareBoundedBodies(ctx, aResp, bResp, c.mode, compareElements)
 	if err != nil {
 		return nil, err
 	}
-	bBody, err := ioutil.ReadAll(bResp.Body)
+	result.merge(c.traced(ctx, "bodies", bodies))
+	if c.compareProtocol {
+		result.merge(c.traced(ctx, "trailers", c.trailerDiffs(aResp, bResp)))
+	}
+	return result, nil
+}
+
+//compareBoundedBodies compares the bodies of the responses by the mode within the maximal body size by its policy
+//and the in-memory limit.
+func (c *Comparator) compareBoundedBodies(ctx context.Context, aResp, bResp *http.Response, mode Mode,
+	compareElements []string) (*Result, error) {
+	aLimited := c.limitBody(aResp, SideA)
+	bLimited := c.limitBody(bResp, SideB)
+	bodies, err := c.compareResponseBodies(ctx, aResp, bResp, mode, compareElements)
+	if err == errBodyHashed {
+		bodies, err = compareHashes(aResp, bResp, aLimited, bLimited)
+	}
 	if err != nil {
 		return nil, err
 	}
-	diff, err := jsonDiffer.Compare(aBody, bBody)
+	bodies.truncated = aLimited.truncated() || bLimited.truncated()
+	return bodies, nil
+}
+
+//compareResponseBodies reads the bodies and compares them in memory, or as streams if they are too large.
+func (c *Comparator) compareResponseBodies(ctx context.Context, aResp, bResp *http.Response, mode Mode,
+	compareElements []string) (*Result, error) {
+	aBody, aLarge, err := c.readBodyLimited(aResp)
 	if err != nil {
+		bResp.Body.Close()
 		return nil, err
 	}
-	json.Unmarshal(aBody, &aJSON)
-	formatter := formatter.NewAsciiFormatter(aJSON)
-	diffString, err := formatter.Format(diff)
+	bBody, bLarge, err := c.readBodyLimited(bResp)
 	if err != nil {
+		if aLarge {
+			aResp.Body.Close()
+		}
 		return nil, err
 	}
-	lines := strings.Split(diffString, "\n")
-	return getDiffsFromStrings(lines), nil
+	if aLarge || bLarge {
+		return c.compareStreams(ctx, remainingBody(aBody, aResp, aLarge), remainingBody(bBody, bResp, bLarge))
+	}
+	return c.compareBodies(ctx, responseURL(aResp), responseURL(bResp), aBody, bBody,
+		aResp.Header.Get("Content-Type"), bResp.Header.Get("Content-Type"), mode, compareElements)
 }
 
-func compareHTMLs(aResp, bResp *http.Response, compareElements []string) ([]Diff, error) {
-	var result []Diff
-	aDoc, err := goquery.NewDocumentFromResponse(aResp)
+//compareBodies compares the bodies by the mode, ModeAuto selects it by the content types. Content types are
+//optional, the mode is sniffed from the bodies if they are not known. Normalizers apply after host substitutions.
+//Registered body comparators take precedence over the modes, urls are empty for payloads compared without fetching.
+func (c *Comparator) compareBodies(ctx context.Context, aURL, bURL string, aBody, bBody []byte, aContentType,
+	bContentType string, mode Mode, compareElements []string) (*Result, error) {
+	aBody, err := c.normalizeBody(ctx, c.prepareBody(aBody), aContentType)
 	if err != nil {
 		return nil, err
 	}
-	bDoc, err := goquery.NewDocumentFromResponse(bResp)
+	bBody, err = c.normalizeBody(ctx, c.prepareBody(bBody), bContentType)
 	if err != nil {
 		return nil, err
 	}
-	for _, element := range compareElements {
-		aElement := aDoc.Find(element)
-		bElement := bDoc.Find(element)
-		result = append(result, compareStrings(aElement.Text(), bElement.Text())...)
+	result, err := c.compareBodiesAs(ctx, aURL, bURL, aBody, bBody, aContentType, bContentType, mode,
+		compareElements)
+	if err != nil {
+		return nil, err
 	}
+	result.bodySize = int64(len(aBody) + len(bBody))
 	return result, nil
 }
 
-func compareStrings(aString, bString string) []Diff {
-	var result []Diff
-	diffs := textDiffer.DiffMain(aString, bString, true)
-	diffs = textDiffer.DiffCleanupSemantic(diffs)
-	for _, element := range diffs {
-		if element.Type == diffmatchpatch.DiffInsert {
-			result = append(result, Diff{element.Text, Insert})
-		} else if element.Type == diffmatchpatch.DiffDelete {
-			result = append(result, Diff{element.Text, Delete})
+//compareBodiesAs compares the prepared bodies by the registered body comparator or by the mode.
+func (c *Comparator) compareBodiesAs(ctx context.Context, aURL, bURL string, aBody, bBody []byte, aContentType,
+	bContentType string, mode Mode, compareElements []string) (*Result, error) {
+	if compareElements != nil && mode != ModeLinks {
+		c.debug(ctx, "comparing html elements", "elements", compareElements)
+		return c.compareHTMLs(ctx, aBody, bBody, compareElements)
+	}
+	if compare, ok := c.registeredComparator(aURL, bURL, aContentType, bContentType, aBody, bBody, mode); ok {
+		c.debug(ctx, "comparing bodies by the registered comparator", "a_content_type", aContentType,
+			"b_content_type", bContentType)
+		result, err := compare(ctx, aBody, bBody)
+		if result == nil && err == nil {
+			result = &Result{}
 		}
+		return result, err
+	}
+	mode = c.detectMode(aBody, bBody, aContentType, bContentType, mode)
+	c.debug(ctx, "comparing bodies", "mode", mode, "a_content_type", aContentType, "b_content_type", bContentType,
+		"a_size", len(aBody), "b_size", len(bBody))
+	switch mode {
+	case ModeHTML:
+		return c.compareHTMLs(ctx, aBody, bBody, nil)
+	case ModeXML:
+		return c.compareXMLs(ctx, aBody, bBody)
+	case ModeYAML:
+		return c.compareYAMLs(ctx, aBody, bBody)
+	case ModeText:
+		return c.compareTextBodies(aBody, bBody), nil
+	case ModeBinary:
+		return compareBinaries(aBody, bBody), nil
+	case ModeImage:
+		return c.compareImages(aBody, bBody)
+	case ModeNDJSON:
+		return c.compareNDJSONs(ctx, aBody, bBody)
+	case ModeCSV:
+		return c.compareCSVs(ctx, aBody, bBody)
+	case ModeProtobuf:
+		return c.compareProtobufs(ctx, aBody, bBody)
+	case ModePDF:
+		return c.comparePDFs(aBody, bBody)
+	case ModeFeed:
+		return c.compareFeeds(ctx, aBody, bBody)
+	case ModeLinks:
+		return c.compareLinks(ctx, aURL, bURL, aBody, bBody, compareElements)
+	case ModeSchema:
+		return c.compareSchemas(ctx, aBody, bBody)
 	}
-	return result
+	return c.compareJSONs(ctx, aBody, bBody)
 }
 
-func getDiffsFromStrings(lines []string) []Diff {
-	var diffs []Diff
-	for _, line := range lines {
-		if strings.HasPrefix(line, "+") {
-			line = strings.Replace(line, "+", "", 1)
-			diffs = append(diffs, Diff{line, Insert})
-		} else if strings.HasPrefix(line, "-") {
-			line = strings.Replace(line, "-", "", 1)
-			diffs = append(diffs, Diff{line, Delete})
-		} else {
-			continue
-		}
+//CompareBytes compares the payloads with the default options. Compare only specified html elements or compare
+//payloads according to their sniffed content type if elements are not provided.
+func CompareBytes(a, b []byte, compareElements []string) ([]Diff, error) {
+	return defaultComparator.CompareBytes(a, b, compareElements)
+}
+
+//CompareReaders reads both payloads and compares them with the default options as CompareBytes does.
+func CompareReaders(a, b io.Reader, compareElements []string) ([]Diff, error) {
+	return defaultComparator.CompareReaders(a, b, compareElements)
+}
+
+//CompareStrings compares the payloads with the default options as CompareBytes does.
+func CompareStrings(a, b string, compareElements []string) ([]Diff, error) {
+	return defaultComparator.CompareStrings(a, b, compareElements)
+}
+
+//CompareBytesResult is like CompareBytes but returns the structured result.
+func CompareBytesResult(a, b []byte, compareElements []string) (*Result, error) {
+	return defaultComparator.CompareBytesResult(a, b, compareElements)
+}
+
+//CompareBytes compares the payloads already available in memory without fetching anything. Compare only
+//specified html elements or compare payloads according to their sniffed content type if elements are not provided.
+func (c *Comparator) CompareBytes(a, b []byte, compareElements []string) ([]Diff, error) {
+	return diffsOf(c.CompareBytesResult(a, b, compareElements))
+}
+
+//CompareReaders reads both payloads and compares them as CompareBytes does.
+func (c *Comparator) CompareReaders(a, b io.Reader, compareElements []string) ([]Diff, error) {
+	aBody, err := ioutil.ReadAll(a)
+	if err != nil {
+		return nil, err
 	}
-	return diffs
+	bBody, err := ioutil.ReadAll(b)
+	if err != nil {
+		return nil, err
+	}
+	return c.CompareBytes(aBody, bBody, compareElements)
+}
+
+//CompareStrings compares the payloads as CompareBytes does.
+func (c *Comparator) CompareStrings(a, b string, compareElements []string) ([]Diff, error) {
+	return c.CompareBytes([]byte(a), []byte(b), compareElements)
+}
+
+//CompareBytesResult is like CompareBytes but returns the structured result.
+func (c *Comparator) CompareBytesResult(a, b []byte, compareElements []string) (*Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	result, err := c.compareBodies(context.Background(), "", "", a, b, "", "", c.mode, compareElements)
+	if err != nil {
+		return nil, err
+	}
+	c.postprocess(result)
+	return result, nil
 }
 
-func trimErrorHost(err error) error {
-	errText := err.Error()
-	errWithoutHost := errText[strings.LastIndex(errText, ":"):len(errText)]
-	return errors.New(errWithoutHost)
+//postprocess applies optional processing to the result of a finished comparison.
+func (c *Comparator) postprocess(result *Result) {
+	if c.detectMoves {
+		result.diffs = markMoves(result.diffs)
+		markMovedChanges(result.Changes)
+	}
+	c.filterDiffs(result)
+	result.severityRules = c.severityRules
 }
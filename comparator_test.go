@@ -0,0 +1,63 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCompareReportsFetchErrorsAsDiffs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1}`)
+	}))
+	defer server.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	diffs, err := New().Compare(server.URL, downURL, nil)
+	if err != nil {
+		t.Fatalf("Compare returned error %v, the fetch error must be reported as diffs", err)
+	}
+	if len(diffs) != 2 || diffs[0].Text != "200 OK" || diffs[1].Type != Insert {
+		t.Fatalf("unexpected diffs %v", diffs)
+	}
+
+	result, err := New().CompareResult(server.URL, downURL, nil)
+	if err != nil {
+		t.Fatalf("CompareResult returned error %v", err)
+	}
+	if !result.SideAOnly() {
+		t.Errorf("SideAOnly is false for the b side being down")
+	}
+	if errs := result.FetchErrors(); len(errs) != 1 || errs[0].Side != SideB || errs[0].URL != downURL {
+		t.Errorf("unexpected fetch errors %v", errs)
+	}
+}
+
+func TestCompareBothSidesFailingEqually(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	aURL := down.URL + "/a"
+	bURL := down.URL + "/b"
+	down.Close()
+
+	result, err := New().CompareResult(aURL, bURL, nil)
+	if err != nil {
+		t.Fatalf("CompareResult returned error %v", err)
+	}
+	if len(result.Changes) > 0 {
+		t.Errorf("the same failure of both sides is reported as changes %v", result.Changes)
+	}
+	if len(result.FetchErrors()) != 2 {
+		t.Errorf("unexpected fetch errors %v", result.FetchErrors())
+	}
+	if result.Equal() || result.Passed(SeverityCritical) {
+		t.Errorf("both sides being down is treated as passing")
+	}
+	suite := RunSuite(context.Background(), Suite{Pairs: []SuitePair{{A: aURL, B: bURL}}})
+	if suite.Failed != 1 || suite.Passed() {
+		t.Errorf("both sides being down is not a failed pair of the suite: %+v", suite)
+	}
+}
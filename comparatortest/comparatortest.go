@@ -0,0 +1,146 @@
+//Package comparatortest integrates the comparator into go tests. The assertions fail the test with the rendered
+//changes, golden files and snapshots are rewritten instead of compared when the tests run with the -update flag:
+//
+//	func TestCheckout(t *testing.T) {
+//		comparatortest.AssertEqualResponses(t, legacy.URL+"/checkout", rewrite.URL+"/checkout",
+//			comparator.WithStatusComparison())
+//		comparatortest.AssertGolden(t, rewrite.URL+"/cart", "testdata/cart.json")
+//	}
+package comparatortest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Rozakh/comparator"
+)
+
+//update rewrites the golden files and snapshots, test packages using comparatortest must not define an update flag
+//themselves.
+var update = flag.Bool("update", false, "update the golden files and snapshots of comparatortest")
+
+//Update reports whether the golden files and snapshots are rewritten, either by the -update flag or by the
+//COMPARATOR_UPDATE environment variable.
+func Update() bool {
+	return *update || os.Getenv("COMPARATOR_UPDATE") != ""
+}
+
+//AssertEqualResponses compares the responses of the urls by the options and fails the test with the changes if
+//they differ. The result is returned for further assertions, nil if the comparison failed.
+func AssertEqualResponses(t testing.TB, aURL, bURL string, options ...comparator.Option) *comparator.Result {
+	t.Helper()
+	result, err := comparator.New(options...).CompareResultContext(context.Background(), aURL, bURL, nil)
+	if err != nil {
+		t.Errorf("comparing %s with %s: %v", aURL, bURL, err)
+		return nil
+	}
+	if !result.Equal() {
+		t.Errorf("responses of %s and %s differ:\n%s", aURL, bURL, describe(result))
+	}
+	return result
+}
+
+//AssertEqualBodies compares the bodies by the options and fails the test with the changes if they differ.
+func AssertEqualBodies(t testing.TB, a, b []byte, options ...comparator.Option) *comparator.Result {
+	t.Helper()
+	result, err := comparator.New(options...).CompareBytesResult(a, b, nil)
+	if err != nil {
+		t.Errorf("comparing bodies: %v", err)
+		return nil
+	}
+	if !result.Equal() {
+		t.Errorf("bodies differ:\n%s", describe(result))
+	}
+	return result
+}
+
+//AssertGolden compares the body fetched from the url with the golden file and fails the test with the changes if
+//they differ, the golden file is the a side. With -update the body is written to the golden file instead, creating
+//its directory. Missing golden files fail the test.
+func AssertGolden(t testing.TB, url, path string, options ...comparator.Option) *comparator.Result {
+	t.Helper()
+	c := comparator.New(options...)
+	if Update() {
+		snapshot, err := c.TakeSnapshot(context.Background(), url)
+		if err != nil {
+			t.Errorf("fetching %s: %v", url, err)
+			return nil
+		}
+		if err := writeFile(path, snapshot.Body); err != nil {
+			t.Errorf("updating golden file %s: %v", path, err)
+		}
+		return &comparator.Result{}
+	}
+	result, err := c.CompareWithGolden(context.Background(), url, path, comparator.ModeAuto)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Errorf("golden file %s is missing, run the test with -update to create it", path)
+		return nil
+	}
+	if err != nil {
+		t.Errorf("comparing %s with golden file %s: %v", url, path, err)
+		return nil
+	}
+	if !result.Equal() {
+		t.Errorf("response of %s differs from golden file %s, run the test with -update if the change is "+
+			"intended:\n%s", url, path, describe(result))
+	}
+	return result
+}
+
+//AssertSnapshot compares the response of the url with the snapshot saved to the file, including its status and
+//headers if the options compare them, and fails the test with the changes if they differ. With -update the
+//response is saved to the file instead.
+func AssertSnapshot(t testing.TB, url, path string, options ...comparator.Option) *comparator.Result {
+	t.Helper()
+	c := comparator.New(options...)
+	if Update() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Errorf("updating snapshot %s: %v", path, err)
+			return nil
+		}
+		if err := c.RecordSnapshot(context.Background(), url, path); err != nil {
+			t.Errorf("updating snapshot %s: %v", path, err)
+		}
+		return &comparator.Result{}
+	}
+	result, err := c.CompareSnapshotFile(context.Background(), path, url, nil)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Errorf("snapshot %s is missing, run the test with -update to create it", path)
+		return nil
+	}
+	if err != nil {
+		t.Errorf("comparing %s with snapshot %s: %v", url, path, err)
+		return nil
+	}
+	if !result.Equal() {
+		t.Errorf("response of %s differs from snapshot %s, run the test with -update if the change is intended:\n%s",
+			url, path, describe(result))
+	}
+	return result
+}
+
+//describe renders the fetch errors and the changes of the result for failure messages.
+func describe(result *comparator.Result) string {
+	var buf bytes.Buffer
+	for _, err := range result.FetchErrors() {
+		fmt.Fprintln(&buf, err)
+	}
+	if err := result.WriteTerminal(&buf, comparator.TerminalOptions{Color: comparator.ColorNever}); err != nil {
+		return fmt.Sprintf("%v %v", result.FetchErrors(), result.Changes)
+	}
+	return buf.String()
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,176 @@
+package comparatortest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//fakeTB records the failures of the assertions instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	failures []string
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+//assertFailure checks that the assertion failed once with a message containing all parts.
+func assertFailure(t *testing.T, tb *fakeTB, parts ...string) {
+	t.Helper()
+	if len(tb.failures) != 1 {
+		t.Fatalf("got failures %q, want one", tb.failures)
+	}
+	for _, part := range parts {
+		if !strings.Contains(tb.failures[0], part) {
+			t.Errorf("failure %q doesn't contain %q", tb.failures[0], part)
+		}
+	}
+}
+
+//bodyServer serves the body, which may be changed by the test.
+func bodyServer(body *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, *body)
+	}))
+}
+
+//setUpdate sets the -update flag until the test ends.
+func setUpdate(t *testing.T) {
+	*update = true
+	t.Cleanup(func() { *update = false })
+}
+
+func TestAssertEqualResponses(t *testing.T) {
+	aBody, bBody := `{"id":1,"name":"a"}`, `{"id":1,"name":"a"}`
+	a, b := bodyServer(&aBody), bodyServer(&bBody)
+	defer a.Close()
+	defer b.Close()
+
+	tb := &fakeTB{}
+	if result := AssertEqualResponses(tb, a.URL, b.URL); result == nil || len(tb.failures) > 0 {
+		t.Fatalf("equal responses failed with %q", tb.failures)
+	}
+
+	bBody = `{"id":1,"name":"b"}`
+	tb = &fakeTB{}
+	AssertEqualResponses(tb, a.URL, b.URL)
+	assertFailure(t, tb, "responses of "+a.URL+" and "+b.URL+" differ", "/name")
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+	tb = &fakeTB{}
+	AssertEqualResponses(tb, a.URL, downURL)
+	assertFailure(t, tb, "fetch b side "+downURL)
+}
+
+func TestAssertEqualBodies(t *testing.T) {
+	tb := &fakeTB{}
+	AssertEqualBodies(tb, []byte(`{"a":[1,2]}`), []byte(`{"a":[1,2]}`))
+	if len(tb.failures) > 0 {
+		t.Fatalf("equal bodies failed with %q", tb.failures)
+	}
+	AssertEqualBodies(tb, []byte(`{"a":[1,2]}`), []byte(`{"a":[1,3]}`))
+	assertFailure(t, tb, "bodies differ", "/a/1")
+}
+
+func TestAssertGolden(t *testing.T) {
+	body := `{"items":[1,2]}`
+	server := bodyServer(&body)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "comparatortest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "testdata", "items.json")
+
+	tb := &fakeTB{}
+	AssertGolden(tb, server.URL, path)
+	assertFailure(t, tb, "golden file "+path+" is missing", "-update")
+
+	setUpdate(t)
+	tb = &fakeTB{}
+	AssertGolden(tb, server.URL, path)
+	if len(tb.failures) > 0 {
+		t.Fatalf("updating the golden file failed with %q", tb.failures)
+	}
+	if golden, err := ioutil.ReadFile(path); err != nil || string(golden) != body {
+		t.Fatalf("golden file is %q, %v", golden, err)
+	}
+	*update = false
+
+	tb = &fakeTB{}
+	AssertGolden(tb, server.URL, path)
+	if len(tb.failures) > 0 {
+		t.Fatalf("the unchanged response failed with %q", tb.failures)
+	}
+	body = `{"items":[1,3]}`
+	tb = &fakeTB{}
+	AssertGolden(tb, server.URL, path)
+	assertFailure(t, tb, "differs from golden file "+path, "/items/1", "-update if the change is intended")
+}
+
+func TestAssertSnapshot(t *testing.T) {
+	body := `{"status":"ok"}`
+	server := bodyServer(&body)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "comparatortest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshots", "status.json")
+
+	tb := &fakeTB{}
+	AssertSnapshot(tb, server.URL, path)
+	assertFailure(t, tb, "snapshot "+path+" is missing")
+
+	os.Setenv("COMPARATOR_UPDATE", "1")
+	defer os.Unsetenv("COMPARATOR_UPDATE")
+	tb = &fakeTB{}
+	AssertSnapshot(tb, server.URL, path)
+	if len(tb.failures) > 0 {
+		t.Fatalf("updating the snapshot failed with %q", tb.failures)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("snapshot is not written: %v", err)
+	}
+	os.Unsetenv("COMPARATOR_UPDATE")
+
+	tb = &fakeTB{}
+	AssertSnapshot(tb, server.URL, path)
+	if len(tb.failures) > 0 {
+		t.Fatalf("the unchanged response failed with %q", tb.failures)
+	}
+	body = `{"status":"down"}`
+	tb = &fakeTB{}
+	AssertSnapshot(tb, server.URL, path)
+	assertFailure(t, tb, "differs from snapshot "+path, "/status")
+}
+
+func TestUpdate(t *testing.T) {
+	if Update() {
+		t.Skip("the test runs with -update")
+	}
+	os.Setenv("COMPARATOR_UPDATE", "1")
+	defer os.Unsetenv("COMPARATOR_UPDATE")
+	if !Update() {
+		t.Errorf("COMPARATOR_UPDATE doesn't update")
+	}
+	os.Unsetenv("COMPARATOR_UPDATE")
+	setUpdate(t)
+	if !Update() {
+		t.Errorf("the -update flag doesn't update")
+	}
+}
@@ -0,0 +1,102 @@
+package comparator
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+//cookieAttribute extracts one attribute of a cookie as comparable text.
+type cookieAttribute struct {
+	name  string
+	value func(*http.Cookie) string
+}
+
+var cookieAttributes = []cookieAttribute{
+	{"Value", func(c *http.Cookie) string { return c.Value }},
+	{"Path", func(c *http.Cookie) string { return c.Path }},
+	{"Domain", func(c *http.Cookie) string { return c.Domain }},
+	{"Secure", func(c *http.Cookie) string { return strconv.FormatBool(c.Secure) }},
+	{"HttpOnly", func(c *http.Cookie) string { return strconv.FormatBool(c.HttpOnly) }},
+	{"SameSite", func(c *http.Cookie) string { return sameSiteName(c.SameSite) }},
+	{"Max-Age", func(c *http.Cookie) string { return strconv.Itoa(c.MaxAge) }},
+}
+
+//cookieDiffs matches cookies set by the responses by name and reports every differing attribute as a separate
+//pair of diffs. Cookies set by only one of the responses are reported as a whole, ignored values are skipped.
+func (c *Comparator) cookieDiffs(aResp, bResp *http.Response) *Result {
+	aCookies := cookiesByName(aResp)
+	bCookies := cookiesByName(bResp)
+	result := &Result{}
+	for _, name := range cookieNames(aCookies, bCookies) {
+		aCookie, aOK := aCookies[name]
+		bCookie, bOK := bCookies[name]
+		if !bOK {
+			result.add(Change{"cookie/" + name, Removed, aCookie.String(), nil},
+				Diff{"Set-Cookie: " + aCookie.String(), Delete})
+			continue
+		}
+		if !aOK {
+			result.add(Change{"cookie/" + name, Added, nil, bCookie.String()},
+				Diff{"Set-Cookie: " + bCookie.String(), Insert})
+			continue
+		}
+		for _, attribute := range cookieAttributes {
+			if attribute.name == "Value" && c.ignoresCookieValue(name) {
+				continue
+			}
+			aValue := attribute.value(aCookie)
+			bValue := attribute.value(bCookie)
+			if aValue != bValue {
+				result.add(Change{"cookie/" + name + "/" + attribute.name, Modified, aValue, bValue},
+					Diff{fmt.Sprintf("Set-Cookie %s %s: %s", name, attribute.name, aValue), Delete},
+					Diff{fmt.Sprintf("Set-Cookie %s %s: %s", name, attribute.name, bValue), Insert})
+			}
+		}
+	}
+	return result
+}
+
+//cookiesByName indexes cookies of the response by name. The first one wins if the name is repeated.
+func cookiesByName(resp *http.Response) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range resp.Cookies() {
+		if _, ok := cookies[cookie.Name]; !ok {
+			cookies[cookie.Name] = cookie
+		}
+	}
+	return cookies
+}
+
+func cookieNames(aCookies, bCookies map[string]*http.Cookie) []string {
+	var names []string
+	for name := range aCookies {
+		names = append(names, name)
+	}
+	for name := range bCookies {
+		if _, ok := aCookies[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sameSiteName(sameSite http.SameSite) string {
+	switch sameSite {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
+
+//ignoresCookieValue reports whether the value of the cookie is not compared. Empty but not nil ignored values
+//ignore values of all cookies.
+func (c *Comparator) ignoresCookieValue(name string) bool {
+	return c.cookieValues != nil && (len(c.cookieValues) == 0 || c.cookieValues[name])
+}
@@ -0,0 +1,113 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+//compareCSVs compares csv bodies with header rows. Columns are aligned by their names, so reordered columns don't
+//make a difference, and ignored columns are skipped. Added and removed columns are reported once at "/columns"
+//and only the common columns of the rows are compared. Rows are matched by the record key column like records of
+//newline delimited json and addressed by the key and the column name, like /42/price.
+func (c *Comparator) compareCSVs(ctx context.Context, aBody, bBody []byte) (*Result, error) {
+	aHeader, aRows, err := decodeCSV(aBody)
+	if err != nil {
+		return nil, &ParseError{SideA, ModeCSV, err}
+	}
+	bHeader, bRows, err := decodeCSV(bBody)
+	if err != nil {
+		return nil, &ParseError{SideB, ModeCSV, err}
+	}
+	result := &Result{}
+	common := make(map[string]bool)
+	var removed, added []string
+	bColumns := make(map[string]bool, len(bHeader))
+	for _, column := range bHeader {
+		bColumns[column] = true
+	}
+	for _, column := range aHeader {
+		if c.ignoredColumns[column] {
+			continue
+		}
+		if bColumns[column] {
+			common[column] = true
+		} else {
+			removed = append(removed, column)
+		}
+	}
+	for _, column := range bHeader {
+		if !c.ignoredColumns[column] && !common[column] {
+			added = append(added, column)
+		}
+	}
+	if len(removed) > 0 || len(added) > 0 {
+		change := Change{Path: "/columns", Kind: changeKind(len(removed) > 0, len(added) > 0)}
+		var diffs []Diff
+		if len(removed) > 0 {
+			change.Old = removed
+			diffs = append(diffs, Diff{"Columns: " + strings.Join(removed, ", "), Delete})
+		}
+		if len(added) > 0 {
+			change.New = added
+			diffs = append(diffs, Diff{"Columns: " + strings.Join(added, ", "), Insert})
+		}
+		result.add(change, diffs...)
+	}
+	aRecords := csvRecords(aHeader, aRows, common)
+	bRecords := csvRecords(bHeader, bRows, common)
+	var rows *Result
+	if aKeyed, bKeyed, ok := c.keyBoth(aRecords, bRecords); ok {
+		rows, err = c.compareTrees(ctx, []interface{}{aKeyed}, []interface{}{bKeyed}, "", "")
+	} else {
+		rows, err = c.compareTrees(ctx, []interface{}{aRecords}, []interface{}{bRecords}, "", "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.merge(rows)
+	return result, nil
+}
+
+//decodeCSV parses the header and the rows of the body.
+func decodeCSV(body []byte) ([]string, [][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("csv: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+	return rows[0], rows[1:], nil
+}
+
+//csvRecords turns the rows into records with the columns as members. Only the compared columns are kept.
+func csvRecords(header []string, rows [][]string, columns map[string]bool) []interface{} {
+	records := make([]interface{}, len(rows))
+	for i, row := range rows {
+		record := make(map[string]interface{}, len(columns))
+		for j, value := range row {
+			if j < len(header) && columns[header[j]] {
+				record[header[j]] = value
+			}
+		}
+		records[i] = record
+	}
+	return records
+}
+
+//keyBoth maps the records of both sides by the record key if it is set and both sides are keyed by it.
+func (c *Comparator) keyBoth(aRecords, bRecords []interface{}) (map[string]interface{}, map[string]interface{},
+	bool) {
+	if c.recordKey == "" {
+		return nil, nil, false
+	}
+	aKeyed, aOK := keyRecords(aRecords, c.recordKey)
+	bKeyed, bOK := keyRecords(bRecords, c.recordKey)
+	return aKeyed, bKeyed, aOK && bOK
+}
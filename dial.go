@@ -0,0 +1,92 @@
+package comparator
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//transportKey identifies the dial overrides and the protocol of the request, it is empty if there are none.
+func (r *Request) transportKey() string {
+	if len(r.Resolve) == 0 && r.ServerName == "" && r.Protocol == ProtocolAuto {
+		return ""
+	}
+	hosts := make([]string, 0, len(r.Resolve))
+	for host, address := range r.Resolve {
+		hosts = append(hosts, host+"="+address)
+	}
+	sort.Strings(hosts)
+	return strings.Join(hosts, ",") + ";" + r.ServerName + ";" + r.Protocol.String()
+}
+
+//resolveAddress returns the address the host and port of the request address are dialed at. Overrides of the
+//host and port take precedence over the ones of the host, overriding addresses without a port keep the port of the
+//request.
+func resolveAddress(resolve map[string]string, address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	target, ok := resolve[address]
+	if !ok {
+		if target, ok = resolve[host]; !ok {
+			return address
+		}
+	}
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		return net.JoinHostPort(strings.Trim(target, "[]"), port)
+	}
+	return target
+}
+
+//sideClient returns the client sending the requests of the side. Requests with dial overrides or a protocol are
+//sent by a copy of the client with a transport dialing the overriding addresses, sending the overriding tls server
+//name and speaking the protocol. The transports are kept by their keys, so that their connections are reused.
+func (c *Comparator) sideClient(request *Request) (*http.Client, error) {
+	client := c.redirectClient()
+	key := request.transportKey()
+	if key == "" {
+		return client, nil
+	}
+	transport, ok := c.transports.Load(key)
+	if !ok {
+		base := http.DefaultTransport
+		if client.Transport != nil {
+			base = client.Transport
+		}
+		baseTransport, ok := base.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("dial overrides and protocols need an *http.Transport, the client has %T", base)
+		}
+		dial := baseTransport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		resolve := make(map[string]string, len(request.Resolve))
+		for host, address := range request.Resolve {
+			resolve[host] = address
+		}
+		if len(resolve) > 0 {
+			baseDial := dial
+			dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+				return baseDial(ctx, network, resolveAddress(resolve, address))
+			}
+		}
+		tlsConfig := &tls.Config{}
+		if baseTransport.TLSClientConfig != nil {
+			tlsConfig = baseTransport.TLSClientConfig.Clone()
+		}
+		if request.ServerName != "" {
+			tlsConfig.ServerName = request.ServerName
+		}
+		overriding := protocolTransport(baseTransport, request.Protocol, dial, resolve, tlsConfig)
+		transport, _ = c.transports.LoadOrStore(key, overriding)
+	}
+	sideClient := *client
+	sideClient.Transport = transport.(http.RoundTripper)
+	return &sideClient, nil
+}
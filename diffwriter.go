@@ -0,0 +1,65 @@
+package comparator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+//DiffWriter receives the results of the pairs of a streamed batch one by one as soon as they are compared. Results
+//are written one at a time, so a DiffWriter doesn't have to be safe for concurrent use.
+type DiffWriter interface {
+	WriteResult(result BatchResult) error
+}
+
+//DiffWriterFunc adapts a function to the DiffWriter interface.
+type DiffWriterFunc func(result BatchResult) error
+
+//WriteResult calls f(result).
+func (f DiffWriterFunc) WriteResult(result BatchResult) error {
+	return f(result)
+}
+
+//JSONLinesWriter writes every result as a json line like
+//{"a_url":"...","b_url":"...","equal":false,"changes":[...],"error":"..."} to w.
+func JSONLinesWriter(w io.Writer) DiffWriter {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return DiffWriterFunc(func(result BatchResult) error {
+		return encoder.Encode(newWebhookResult(result))
+	})
+}
+
+//StreamBatch compares the pairs concurrently with the default options and writes their results to the writer.
+func StreamBatch(ctx context.Context, pairs []Pair, writer DiffWriter) error {
+	return defaultComparator.StreamBatch(ctx, pairs, writer)
+}
+
+//StreamBatch compares the pairs concurrently by the configured number of workers like CompareBatch does, but
+//writes the result of every pair to the writer once it is compared instead of collecting them, so that results of
+//large batches are not kept in memory. Results are written in the order the comparisons finish. The first error
+//of the writer stops the batch and is returned, pairs not compared before the context is done are not written and
+//the context error is returned.
+func (c *Comparator) StreamBatch(ctx context.Context, pairs []Pair, writer DiffWriter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var mu sync.Mutex
+	var writeErr error
+	c.forEach(ctx, len(pairs), func(i int) {
+		pair := pairs[i]
+		result, err := c.CompareResultContext(ctx, pair.AURL, pair.BURL, pair.Elements)
+		mu.Lock()
+		defer mu.Unlock()
+		if writeErr != nil {
+			return
+		}
+		if writeErr = writer.WriteResult(BatchResult{pair, result, err}); writeErr != nil {
+			cancel()
+		}
+	}, func(int) {})
+	if writeErr != nil {
+		return writeErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,90 @@
+package comparator
+
+import (
+	"errors"
+	"fmt"
+)
+
+//Side is one of the compared sides.
+type Side int8
+
+//Compared sides.
+const (
+	SideA Side = iota + 1
+	SideB
+)
+
+func (s Side) String() string {
+	switch s {
+	case SideA:
+		return "a"
+	case SideB:
+		return "b"
+	}
+	return "unknown"
+}
+
+//FetchError tells that the response of a side could not be fetched, like when the endpoint is down or the fetch
+//timeout is exceeded.
+type FetchError struct {
+	Side Side
+	URL  string
+	Err  error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("fetch %s side %s: %v", e.Side, e.URL, e.Err)
+}
+
+//Unwrap returns the cause of the error.
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+//ParseError tells that the body of a side could not be parsed in the mode it was compared in.
+type ParseError struct {
+	Side Side
+	Mode Mode
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parse %s side as %s: %v", e.Side, e.Mode, e.Err)
+}
+
+//Unwrap returns the cause of the error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+//BodySizeError tells that the body of a side exceeds the maximal body size.
+type BodySizeError struct {
+	Side  Side
+	URL   string
+	Limit int64
+}
+
+func (e *BodySizeError) Error() string {
+	return fmt.Sprintf("body of %s side %s exceeds %d bytes", e.Side, e.URL, e.Limit)
+}
+
+//fetchErrors wraps the fetch errors of the sides that failed.
+func fetchErrors(aURL string, aErr error, bURL string, bErr error) []*FetchError {
+	var errs []*FetchError
+	if aErr != nil {
+		errs = append(errs, &FetchError{SideA, aURL, aErr})
+	}
+	if bErr != nil {
+		errs = append(errs, &FetchError{SideB, bURL, bErr})
+	}
+	return errs
+}
+
+//fetchFailure returns the message of the root cause of the fetch error, without the urls and the addresses it is
+//wrapped with, so that the same failure of both sides is reported equal.
+func fetchFailure(err error) string {
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(err) {
+		err = cause
+	}
+	return err.Error()
+}
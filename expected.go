@@ -0,0 +1,60 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+)
+
+//CompareWithExpected compares the body fetched from the url with the expected one using the default options.
+func CompareWithExpected(ctx context.Context, url string, expected []byte, mode Mode) (*Result, error) {
+	return defaultComparator.CompareWithExpected(ctx, url, expected, mode)
+}
+
+//CompareWithGolden compares the body fetched from the url with the golden file using the default options.
+func CompareWithGolden(ctx context.Context, url, path string, mode Mode) (*Result, error) {
+	return defaultComparator.CompareWithGolden(ctx, url, path, mode)
+}
+
+//CompareWithExpected fetches the url as the b side and compares its body with the expected one as the a side, so a
+//single live endpoint is validated against a known good fixture. The bodies are compared by the mode, ModeAuto
+//keeps the mode of the comparator. Only the bodies are compared, there is no expected status, headers or cookies.
+//The maximal body size and the in-memory limit apply to both bodies as to live responses.
+func (c *Comparator) CompareWithExpected(ctx context.Context, url string, expected []byte, mode Mode) (*Result,
+	error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if mode == ModeAuto {
+		mode = c.mode
+	}
+	fetchCtx := ctx
+	if c.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, c.fetchTimeout)
+		defer cancel()
+	}
+	resp, err := c.get(fetchCtx, url, &c.bRequest)
+	if err != nil {
+		return nil, &FetchError{SideB, url, err}
+	}
+	expectedResp := &http.Response{Header: http.Header{}, Body: ioutil.NopCloser(bytes.NewReader(expected)),
+		ContentLength: int64(len(expected))}
+	result, err := c.compareBoundedBodies(ctx, expectedResp, resp, mode, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.postprocess(result)
+	return result, nil
+}
+
+//CompareWithGolden compares the body fetched from the url with the content of the golden file as
+//CompareWithExpected does.
+func (c *Comparator) CompareWithGolden(ctx context.Context, url, path string, mode Mode) (*Result, error) {
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.CompareWithExpected(ctx, url, expected, mode)
+}
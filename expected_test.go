@@ -0,0 +1,41 @@
+package comparator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//endlessHandler streams lines until the client goes away.
+func endlessHandler(w http.ResponseWriter, r *http.Request) {
+	line := []byte(strings.Repeat("x", 1023) + "\n")
+	for r.Context().Err() == nil {
+		if _, err := w.Write(line); err != nil {
+			return
+		}
+	}
+}
+
+func TestCompareWithExpectedMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(endlessHandler))
+	defer server.Close()
+	expected := []byte(strings.Repeat("x", 1023) + "\n")
+
+	_, err := New(WithMaxBodySize(1<<20, BodySizeFail)).CompareWithExpected(context.Background(), server.URL,
+		expected, ModeText)
+	var sizeErr *BodySizeError
+	if !errors.As(err, &sizeErr) || sizeErr.Side != SideB {
+		t.Fatalf("got error %v, want the body size error of the b side", err)
+	}
+	result, err := New(WithMaxBodySize(1024, BodySizeTruncate)).CompareWithExpected(context.Background(),
+		server.URL, expected, ModeText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Truncated() || !result.Equal() {
+		t.Errorf("truncated %v, changes %v", result.Truncated(), result.Changes)
+	}
+}
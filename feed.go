@@ -0,0 +1,228 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+//rssFeed is an rss 2.0 document. The volatile lastBuildDate and pubDate of the channel are not decoded.
+type rssFeed struct {
+	Channel struct {
+		Title       string    `xml:"title"`
+		Link        string    `xml:"link"`
+		Description string    `xml:"description"`
+		Items       []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssItem struct {
+	GUID        string   `xml:"guid"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string   `xml:"author"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+}
+
+//atomFeed is an atom document. The volatile updated date of the feed is not decoded.
+type atomFeed struct {
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID         string     `xml:"id"`
+	Title      string     `xml:"title"`
+	Links      []atomLink `xml:"link"`
+	Summary    string     `xml:"summary"`
+	Content    string     `xml:"content"`
+	Published  string     `xml:"published"`
+	Updated    string     `xml:"updated"`
+	Authors    []string   `xml:"author>name"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+//compareFeeds compares rss or atom feeds by their entries matched by their guids or ids, or by their links if they
+//have none, so that reordered entries are not reported. The feeds are converted into the json tree form, so json
+//options like ignored paths apply. The entries are addressed by their keys, like /entries/<guid>/title, and the
+//feed itself by its members, like /title. The build and update dates of the feeds are ignored.
+func (c *Comparator) compareFeeds(ctx context.Context, aBody, bBody []byte) (*Result, error) {
+	aFeed, err := decodeFeed(aBody)
+	if err != nil {
+		return nil, &ParseError{SideA, ModeFeed, err}
+	}
+	bFeed, err := decodeFeed(bBody)
+	if err != nil {
+		return nil, &ParseError{SideB, ModeFeed, err}
+	}
+	return c.compareTrees(ctx, []interface{}{aFeed}, []interface{}{bFeed}, "", "")
+}
+
+//feedRoot returns the local name of the root element of the xml body, like rss or feed.
+func feedRoot(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", errors.New("no root element")
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+//isFeed reports whether the body is an rss or atom document.
+func isFeed(body []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("<")) {
+		return false
+	}
+	root, err := feedRoot(body)
+	return err == nil && (root == "rss" || root == "feed")
+}
+
+//decodeFeed converts the rss or atom document into the json tree form.
+func decodeFeed(body []byte) (interface{}, error) {
+	root, err := feedRoot(body)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+	switch root {
+	case "rss":
+		var feed rssFeed
+		if err := decoder.Decode(&feed); err != nil {
+			return nil, err
+		}
+		return rssTree(&feed), nil
+	case "feed":
+		var feed atomFeed
+		if err := decoder.Decode(&feed); err != nil {
+			return nil, err
+		}
+		return atomTree(&feed), nil
+	}
+	return nil, errors.New("not an rss or atom feed: root element " + root)
+}
+
+func rssTree(feed *rssFeed) map[string]interface{} {
+	tree := make(map[string]interface{})
+	setText(tree, "title", feed.Channel.Title)
+	setText(tree, "link", feed.Channel.Link)
+	setText(tree, "description", feed.Channel.Description)
+	entries := make(map[string]interface{})
+	for _, item := range feed.Channel.Items {
+		entry := make(map[string]interface{})
+		setText(entry, "title", item.Title)
+		setText(entry, "link", item.Link)
+		setText(entry, "description", item.Description)
+		setText(entry, "content", item.Content)
+		setText(entry, "author", item.Author)
+		setText(entry, "published", item.PubDate)
+		setCategories(entry, item.Categories)
+		addEntry(entries, firstText(item.GUID, item.Link, item.Title), entry)
+	}
+	tree["entries"] = entries
+	return tree
+}
+
+func atomTree(feed *atomFeed) map[string]interface{} {
+	tree := make(map[string]interface{})
+	setText(tree, "title", feed.Title)
+	setText(tree, "link", alternateLink(feed.Links))
+	setText(tree, "description", feed.Subtitle)
+	entries := make(map[string]interface{})
+	for _, item := range feed.Entries {
+		entry := make(map[string]interface{})
+		link := alternateLink(item.Links)
+		setText(entry, "title", item.Title)
+		setText(entry, "link", link)
+		setText(entry, "description", item.Summary)
+		setText(entry, "content", item.Content)
+		setText(entry, "author", strings.Join(item.Authors, ", "))
+		setText(entry, "published", item.Published)
+		setText(entry, "updated", item.Updated)
+		categories := make([]string, len(item.Categories))
+		for i, category := range item.Categories {
+			categories[i] = category.Term
+		}
+		setCategories(entry, categories)
+		addEntry(entries, firstText(item.ID, link, item.Title), entry)
+	}
+	tree["entries"] = entries
+	return tree
+}
+
+//setText sets the member to the trimmed text unless it is empty.
+func setText(tree map[string]interface{}, name, text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		tree[name] = text
+	}
+}
+
+//setCategories sets the sorted categories, their order doesn't matter.
+func setCategories(entry map[string]interface{}, categories []string) {
+	if len(categories) == 0 {
+		return
+	}
+	sorted := append([]string(nil), categories...)
+	sort.Strings(sorted)
+	values := make([]interface{}, len(sorted))
+	for i, category := range sorted {
+		values[i] = strings.TrimSpace(category)
+	}
+	entry["categories"] = values
+}
+
+//addEntry adds the entry by its key, repeated keys are numbered like <key>#2.
+func addEntry(entries map[string]interface{}, key string, entry map[string]interface{}) {
+	unique := key
+	for i := 2; entries[unique] != nil; i++ {
+		unique = key + "#" + strconv.Itoa(i)
+	}
+	entries[unique] = entry
+}
+
+//alternateLink returns the link to the page of an atom feed or entry.
+func alternateLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+//firstText returns the first text that is not empty.
+func firstText(texts ...string) string {
+	for _, text := range texts {
+		if text = strings.TrimSpace(text); text != "" {
+			return text
+		}
+	}
+	return ""
+}
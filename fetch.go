@@ -0,0 +1,254 @@
+package comparator
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html/charset"
+)
+
+//charsetPrefix is the length of the beginning of html bodies searched for the charset declared by a meta element.
+const charsetPrefix = 1024
+
+//Request describes how the response of one side is requested. The zero value is a plain GET request.
+type Request struct {
+	//Method defaults to GET.
+	Method string
+	//Header is added to the request, a Host header overrides the host of the request.
+	Header  http.Header
+	Body    []byte
+	Cookies []*http.Cookie
+	//Retry overrides the retry policy of the comparator for the side if it retries.
+	Retry RetryPolicy
+	//Auth authenticates every request of the side after the headers and the cookies are added.
+	Auth Authenticator
+	//Resolve overrides the addresses the hosts of the side are dialed at, like "api.example.com:443" or
+	//"api.example.com" to "10.0.0.5:443", so that the side targets a specific instance behind a load balancer.
+	//Hosts without a port match any port, addresses without a port keep the port of the url.
+	Resolve map[string]string
+	//ServerName overrides the tls server name sent by the side, the host of the url by default.
+	ServerName string
+	//Protocol forces the http protocol of the side, it is negotiated by default.
+	Protocol Protocol
+}
+
+//newHTTPRequest builds http request for the url. The body is copied for every request, so the same Request can
+//be used for both sides and for many comparisons.
+func (r *Request) newHTTPRequest(ctx context.Context, url string) (*http.Request, error) {
+	method := r.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if r.Body != nil {
+		body = bytes.NewReader(r.Body)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range r.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if host := r.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	for _, cookie := range r.Cookies {
+		req.AddCookie(cookie)
+	}
+	req = req.WithContext(ctx)
+	if r.Auth != nil {
+		if err := r.Auth.Authenticate(req); err != nil {
+			return nil, fmt.Errorf("authenticate %s: %w", url, err)
+		}
+	}
+	return req, nil
+}
+
+//get fetches the url as described by the request retrying transient failures by the retry policy. Response hooks
+//apply to the last response.
+func (c *Comparator) get(ctx context.Context, url string, request *Request) (*http.Response, error) {
+	policy := request.Retry
+	if policy.Retries == 0 {
+		policy = c.retry
+	}
+	backoff := policy.Backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.fetch(ctx, url, request)
+		if attempt == policy.Retries || ctx.Err() != nil || !policy.retryable(resp, err) {
+			if err != nil {
+				return nil, err
+			}
+			if err := c.afterResponse(resp); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+		if c.debugging(ctx) {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			c.debug(ctx, "retrying request", "url", url, "attempt", attempt+1, "status", status, "error", err,
+				"backoff", backoff)
+		}
+		closeBody(resp)
+		if err := sleep(ctx, backoff); err != nil {
+			return nil, fmt.Errorf("retry backoff %s: %w", url, err)
+		}
+		backoff = policy.next(backoff)
+	}
+}
+
+//fetch fetches the url as described by the request, decodes the response body if it is compressed and transcodes
+//it into utf-8 unless the decoding is disabled. Pages are rendered instead if the renderer is set, except for HEAD
+//requests which are always fetched. Responses are taken from the response cache if it is set.
+func (c *Comparator) fetch(ctx context.Context, url string, request *Request) (*http.Response, error) {
+	req, err := c.newRequest(ctx, url, request)
+	if err != nil {
+		return nil, err
+	}
+	if c.cache != nil && cacheable(req) {
+		return c.fetchCached(ctx, req, request)
+	}
+	return c.send(ctx, req, request)
+}
+
+//send sends the request once it is allowed by the rate limit of its host and decodes the response as fetch does.
+//The dial overrides and the protocol of the side apply.
+func (c *Comparator) send(ctx context.Context, req *http.Request, request *Request) (*http.Response, error) {
+	client, err := c.sideClient(request)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.hostLimits.acquire(ctx, req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if c.renderer != nil && req.Method != http.MethodHead {
+		c.debug(ctx, "rendering page", "url", req.URL.String())
+		return c.render(ctx, req)
+	}
+	c.debug(ctx, "sending request", "method", req.Method, "url", req.URL.String(), "host", req.Host)
+	start := time.Now()
+	resp, err := client.Do(req)
+	release()
+	if err != nil {
+		c.debug(ctx, "request failed", "method", req.Method, "url", req.URL.String(), "error", err)
+		return nil, err
+	}
+	c.debug(ctx, "received response", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode,
+		"protocol", resp.Proto, "content_type", resp.Header.Get("Content-Type"), "content_encoding",
+		resp.Header.Get("Content-Encoding"), "elapsed", time.Since(start))
+	if c.rawBodies {
+		return resp, nil
+	}
+	if err := decodeContent(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	decodeCharset(resp)
+	return resp, nil
+}
+
+//readBody reads and closes the response body.
+func readBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+//prepareBody applies body transformations before any mode specific parsing. The body is already decompressed at
+//this point, host substitutions are applied to it.
+func (c *Comparator) prepareBody(body []byte) []byte {
+	if c.hostReplacer == nil {
+		return body
+	}
+	return []byte(c.hostReplacer.Replace(string(body)))
+}
+
+//decodingReader closes both the decoder and the original body.
+type decodingReader struct {
+	io.Reader
+	body io.Closer
+}
+
+func (r *decodingReader) Close() error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		closer.Close()
+	}
+	return r.body.Close()
+}
+
+//decodeContent replaces the body of the response compressed with gzip, deflate or brotli with the decompressed
+//one. net/http only decodes gzip transparently and only when it requested the compression itself.
+func decodeContent(resp *http.Response) error {
+	var reader io.Reader
+	var err error
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		reader, err = zlib.NewReader(resp.Body)
+	case "br":
+		reader = brotli.NewReader(resp.Body)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body = &decodingReader{reader, resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+//decodeCharset transcodes the text body of the response in a charset declared by its content type into utf-8.
+//Html bodies without the declaration are transcoded by the charset of their meta element or, if they are not valid
+//utf-8, by the charset a browser would guess. Xml bodies are left to the xml decoder honoring their declaration, so
+//are bodies in unknown charsets.
+func decodeCharset(resp *http.Response) {
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || strings.HasSuffix(mediaType, "xml") {
+		return
+	}
+	label := params["charset"]
+	if label == "" && mediaType != "text/html" {
+		return
+	}
+	body := bufio.NewReader(resp.Body)
+	if label == "" {
+		prefix, _ := body.Peek(charsetPrefix)
+		_, label, _ = charset.DetermineEncoding(prefix, contentType)
+	}
+	reader := io.Reader(body)
+	if label = strings.ToLower(label); label != "utf-8" && label != "utf8" {
+		if transcoded, err := charset.NewReaderLabel(label, body); err == nil {
+			reader = transcoded
+		}
+	}
+	resp.Body = &decodingReader{reader, resp.Body}
+}
+
+func closeBody(resp *http.Response) {
+	if resp != nil {
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,47 @@
+package comparator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestDecodeCompressedJSON(t *testing.T) {
+	const document = `{"id":1,"name":"café","tags":["a","b"]}`
+	encoders := map[string]func(io.Writer) io.WriteCloser{
+		"br":      func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+	}
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, document)
+	}))
+	defer plain.Close()
+	for encoding, encoder := range encoders {
+		var compressed bytes.Buffer
+		writer := encoder(&compressed)
+		io.WriteString(writer, document)
+		writer.Close()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", encoding)
+			w.Write(compressed.Bytes())
+		}))
+		diffs, err := New().Compare(plain.URL, server.URL, nil)
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: Compare returned error %v", encoding, err)
+			continue
+		}
+		if len(diffs) > 0 {
+			t.Errorf("%s: the decoded body differs from the plain one: %v", encoding, diffs)
+		}
+	}
+}
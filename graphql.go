@@ -0,0 +1,77 @@
+package comparator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+//GraphQLQuery is a graphql operation sent to both endpoints.
+type GraphQLQuery struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+//CompareGraphQL sends the query to both endpoints with the default options and compares the responses.
+func CompareGraphQL(ctx context.Context, aURL, bURL string, query GraphQLQuery) (*Result, error) {
+	return defaultComparator.CompareGraphQL(ctx, aURL, bURL, query)
+}
+
+//CompareGraphQL posts the query to both endpoints and compares the json responses structurally. The extensions
+//member, which carries tracing and other server specific details, is ignored, data and errors are compared. Side
+//requests contribute their headers and cookies, the method and the body are replaced by the query.
+func (c *Comparator) CompareGraphQL(ctx context.Context, aURL, bURL string, query GraphQLQuery) (*Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	aRequest := graphQLRequest(c.aRequest, body)
+	bRequest := graphQLRequest(c.bRequest, body)
+	aBody, bBody, err := c.getBodies(ctx, aURL, bURL, &aRequest, &bRequest)
+	if err != nil {
+		return nil, err
+	}
+	aValue, err := decodeGraphQL(aBody)
+	if err != nil {
+		return nil, err
+	}
+	bValue, err := decodeGraphQL(bBody)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.compareTrees(ctx, []interface{}{aValue}, []interface{}{bValue}, "", "")
+	if err != nil {
+		return nil, err
+	}
+	c.postprocess(result)
+	return result, nil
+}
+
+func graphQLRequest(request Request, body []byte) Request {
+	header := http.Header{}
+	for name, values := range request.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set("Content-Type", "application/json")
+	header.Set("Accept", "application/json")
+	request.Method = http.MethodPost
+	request.Header = header
+	request.Body = body
+	return request
+}
+
+//decodeGraphQL decodes the response dropping its extensions.
+func decodeGraphQL(body []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, err
+	}
+	if object, ok := value.(map[string]interface{}); ok {
+		delete(object, "extensions")
+	}
+	return value, nil
+}
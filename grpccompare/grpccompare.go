@@ -0,0 +1,177 @@
+//Package grpccompare compares responses of unary grpc methods of two targets. Request and response messages are
+//built from the method descriptors resolved by server reflection or from provided files, so no generated code is
+//needed.
+package grpccompare
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Rozakh/comparator"
+	"github.com/jhump/protoreflect/grpcreflect"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+//Call describes a unary method invoked on both targets.
+type Call struct {
+	//Method is the fully-qualified method, like "package.Service/Method" or "package.Service.Method".
+	Method string
+	//Request is the json encoded request message. Fields unknown to a target are discarded.
+	Request []byte
+	//Metadata is sent with both calls.
+	Metadata metadata.MD
+	//Files resolve the method, server reflection of every target is used if it is nil.
+	Files *protoregistry.Files
+}
+
+//marshalOptions render responses with proto field names and the default values, so that fields set to the
+//defaults are not reported as missing.
+var marshalOptions = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+//CompareTargets dials both targets with the dial options and compares responses of the call.
+func CompareTargets(ctx context.Context, c *comparator.Comparator, aTarget, bTarget string, call Call,
+	options ...grpc.DialOption) (*comparator.Result, error) {
+	aConn, err := grpc.NewClient(aTarget, options...)
+	if err != nil {
+		return nil, err
+	}
+	defer aConn.Close()
+	bConn, err := grpc.NewClient(bTarget, options...)
+	if err != nil {
+		return nil, err
+	}
+	defer bConn.Close()
+	return Compare(ctx, c, aConn, bConn, call)
+}
+
+//Compare invokes the call on both connections concurrently and compares the responses as json documents with the
+//options of c. Fields are addressed by their proto names, like /items/0/display_name. If any of the calls fails
+//with a status, statuses are compared instead of the responses, their differences are addressed by /code and
+//by /message. Failures before the calls are sent, like an unresolved method or an invalid request, are returned.
+func Compare(ctx context.Context, c *comparator.Comparator, aConn, bConn grpc.ClientConnInterface, call Call) (
+	*comparator.Result, error) {
+	service, method, err := splitMethod(call.Method)
+	if err != nil {
+		return nil, err
+	}
+	if call.Metadata != nil {
+		ctx = metadata.NewOutgoingContext(ctx, call.Metadata)
+	}
+	var aResponse, bResponse []byte
+	var aErr, bErr error
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		aResponse, aErr, err = invoke(groupCtx, aConn, service, method, call)
+		if err != nil {
+			return fmt.Errorf("grpccompare: a side: %w", err)
+		}
+		return nil
+	})
+	group.Go(func() error {
+		var err error
+		bResponse, bErr, err = invoke(groupCtx, bConn, service, method, call)
+		if err != nil {
+			return fmt.Errorf("grpccompare: b side: %w", err)
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	if aErr != nil || bErr != nil {
+		return compareStatuses(c, aErr, bErr)
+	}
+	return c.CompareJSON(aResponse, bResponse)
+}
+
+//splitMethod separates the service and the method names.
+func splitMethod(fullName string) (string, string, error) {
+	fullName = strings.TrimPrefix(fullName, "/")
+	i := strings.LastIndex(fullName, "/")
+	if i < 0 {
+		i = strings.LastIndex(fullName, ".")
+	}
+	if i <= 0 || i == len(fullName)-1 {
+		return "", "", fmt.Errorf("grpccompare: invalid method %q", fullName)
+	}
+	return fullName[:i], fullName[i+1:], nil
+}
+
+//invoke calls the method on the connection and returns the json encoded response or the error of the call, which
+//is compared as its status. Failures of resolving the method, building the request or rendering the response are
+//returned as the last error, the method is not called then.
+func invoke(ctx context.Context, conn grpc.ClientConnInterface, service, method string, call Call) ([]byte, error,
+	error) {
+	descriptor, err := resolveMethod(ctx, conn, service, method, call.Files)
+	if err != nil {
+		return nil, nil, err
+	}
+	request := dynamicpb.NewMessage(descriptor.Input())
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(call.Request, request); err != nil {
+		return nil, nil, fmt.Errorf("request: %w", err)
+	}
+	response := dynamicpb.NewMessage(descriptor.Output())
+	if callErr := conn.Invoke(ctx, "/"+service+"/"+method, request, response); callErr != nil {
+		return nil, callErr, nil
+	}
+	encoded, err := marshalOptions.Marshal(response)
+	return encoded, nil, err
+}
+
+//resolveMethod finds the method descriptor in the files or by server reflection of the connection.
+func resolveMethod(ctx context.Context, conn grpc.ClientConnInterface, service, method string,
+	files *protoregistry.Files) (protoreflect.MethodDescriptor, error) {
+	var serviceDescriptor protoreflect.ServiceDescriptor
+	if files != nil {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if serviceDescriptor, ok = descriptor.(protoreflect.ServiceDescriptor); !ok {
+			return nil, fmt.Errorf("grpccompare: %s is not a service", service)
+		}
+	} else {
+		client := grpcreflect.NewClientAuto(ctx, conn)
+		defer client.Reset()
+		descriptor, err := client.ResolveService(service)
+		if err != nil {
+			return nil, err
+		}
+		serviceDescriptor = descriptor.UnwrapService()
+	}
+	methodDescriptor := serviceDescriptor.Methods().ByName(protoreflect.Name(method))
+	if methodDescriptor == nil {
+		return nil, fmt.Errorf("grpccompare: method %s not found in %s", method, service)
+	}
+	return methodDescriptor, nil
+}
+
+//compareStatuses compares statuses of the calls, at least one of which failed, as json documents with the code
+//and the message members.
+func compareStatuses(c *comparator.Comparator, aErr, bErr error) (*comparator.Result, error) {
+	aStatus, err := statusDocument(aErr)
+	if err != nil {
+		return nil, err
+	}
+	bStatus, err := statusDocument(bErr)
+	if err != nil {
+		return nil, err
+	}
+	return c.CompareJSON(aStatus, bStatus)
+}
+
+func statusDocument(err error) ([]byte, error) {
+	s := status.Convert(err)
+	return json.Marshal(map[string]string{"code": s.Code().String(), "message": s.Message()})
+}
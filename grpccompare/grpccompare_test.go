@@ -0,0 +1,79 @@
+package grpccompare
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Rozakh/comparator"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+//healthConn serves the health service with the statuses of the services and returns a connection to it.
+func healthConn(t *testing.T, statuses map[string]healthpb.HealthCheckResponse_ServingStatus) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	for service, status := range statuses {
+		healthServer.SetServingStatus(service, status)
+	}
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestCompare(t *testing.T) {
+	aConn := healthConn(t, map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"orders": healthpb.HealthCheckResponse_SERVING, "users": healthpb.HealthCheckResponse_SERVING})
+	bConn := healthConn(t, map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"orders": healthpb.HealthCheckResponse_NOT_SERVING})
+	c := comparator.New()
+	check := func(service string) Call {
+		return Call{Method: "grpc.health.v1.Health/Check", Request: []byte(`{"service":"` + service + `"}`),
+			Files: protoregistry.GlobalFiles}
+	}
+
+	result, err := Compare(context.Background(), c, aConn, aConn, check("orders"))
+	if err != nil || !result.Equal() {
+		t.Errorf("equal responses: %v %v", result, err)
+	}
+	result, err = Compare(context.Background(), c, aConn, bConn, check("orders"))
+	if err != nil || len(result.Changes) != 1 || result.Changes[0].Path != "/status" {
+		t.Errorf("different responses: %v %v", result, err)
+	}
+	result, err = Compare(context.Background(), c, aConn, bConn, check("users"))
+	if err != nil || len(result.Changes) == 0 || result.Changes[0].Path != "/code" {
+		t.Errorf("status of the b side: %v %v", result, err)
+	}
+}
+
+func TestCompareFailsBeforeCalls(t *testing.T) {
+	conn := healthConn(t, nil)
+	c := comparator.New()
+	calls := map[string]Call{
+		"invalid request": {Method: "grpc.health.v1.Health/Check", Request: []byte(`{"service":`),
+			Files: protoregistry.GlobalFiles},
+		"unknown method": {Method: "grpc.health.v1.Health/Probe", Request: []byte(`{}`),
+			Files: protoregistry.GlobalFiles},
+	}
+	for name, call := range calls {
+		if result, err := Compare(context.Background(), c, conn, conn, call); err == nil {
+			t.Errorf("%s: no error, result %v", name, result)
+		}
+	}
+}
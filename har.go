@@ -0,0 +1,242 @@
+package comparator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//HAREntry is a request of a HAR file along with the captured response.
+type HAREntry struct {
+	Method string
+	URL    string
+	Body   []byte
+	//Response is nil if the request got no response.
+	Response *Snapshot
+}
+
+//key matches the entries of different captures by the method and the path with the query.
+func (e *HAREntry) key() string {
+	return e.Method + " " + harPath(e.URL)
+}
+
+//HARResult is the comparison of a pair of matched HAR entries. Path is the path with the query of the entry.
+type HARResult struct {
+	Method string
+	Path   string
+	Result *Result
+	Err    error
+}
+
+//harFile is the part of a HAR file that is compared.
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method   string `json:"method"`
+				URL      string `json:"url"`
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+			Response struct {
+				Status  int         `json:"status"`
+				Headers []harHeader `json:"headers"`
+				Content struct {
+					Text     string `json:"text"`
+					Encoding string `json:"encoding"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+//LoadHAR reads entries of the HAR file in their order.
+func LoadHAR(path string) ([]HAREntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file harFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	entries := make([]HAREntry, len(file.Log.Entries))
+	for i, captured := range file.Log.Entries {
+		entry := HAREntry{Method: strings.ToUpper(captured.Request.Method), URL: captured.Request.URL}
+		if captured.Request.PostData != nil {
+			entry.Body = []byte(captured.Request.PostData.Text)
+		}
+		if response := captured.Response; response.Status > 0 {
+			body := []byte(response.Content.Text)
+			if response.Content.Encoding == "base64" {
+				if body, err = base64.StdEncoding.DecodeString(response.Content.Text); err != nil {
+					return nil, err
+				}
+			}
+			entry.Response = &Snapshot{URL: entry.URL, Status: response.Status, Header: harHeaders(response.Headers),
+				Body: body}
+		}
+		entries[i] = entry
+	}
+	return entries, nil
+}
+
+//harHeaders converts the captured headers. Pseudo headers of http/2 are skipped, content is captured decoded, so
+//the encoding headers are skipped too.
+func harHeaders(headers []harHeader) http.Header {
+	header := make(http.Header, len(headers))
+	for _, h := range headers {
+		if strings.HasPrefix(h.Name, ":") || strings.EqualFold(h.Name, "Content-Encoding") ||
+			strings.EqualFold(h.Name, "Content-Length") {
+			continue
+		}
+		header.Add(h.Name, h.Value)
+	}
+	return header
+}
+
+func harPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.RequestURI()
+}
+
+//CompareHARs compares the entries of the HAR files using the default options.
+func CompareHARs(ctx context.Context, aPath, bPath string) ([]HARResult, error) {
+	return defaultComparator.CompareHARs(ctx, aPath, bPath)
+}
+
+//CompareHARWithURL compares the entries of the HAR file with live responses using the default options.
+func CompareHARWithURL(ctx context.Context, path, bBase string) ([]HARResult, error) {
+	return defaultComparator.CompareHARWithURL(ctx, path, bBase)
+}
+
+//CompareHARs compares the captured responses of the HAR files. Entries are matched by the method and the path with
+//the query, repeated requests in the order they were captured. Results follow the order of the entries of the a
+//file, entries captured by one of the files only are reported as an added or removed "entry" change.
+func (c *Comparator) CompareHARs(ctx context.Context, aPath, bPath string) ([]HARResult, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	aEntries, err := LoadHAR(aPath)
+	if err != nil {
+		return nil, err
+	}
+	bEntries, err := LoadHAR(bPath)
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string][]int)
+	for i := range bEntries {
+		key := bEntries[i].key()
+		pending[key] = append(pending[key], i)
+	}
+	matched := make([]bool, len(bEntries))
+	var results []HARResult
+	for i := range aEntries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		aEntry := &aEntries[i]
+		key := aEntry.key()
+		if len(pending[key]) == 0 {
+			results = append(results, unmatchedEntry(aEntry, Removed))
+			continue
+		}
+		j := pending[key][0]
+		pending[key] = pending[key][1:]
+		matched[j] = true
+		result, err := c.compareEntries(ctx, aEntry.Response, bEntries[j].Response)
+		results = append(results, HARResult{aEntry.Method, harPath(aEntry.URL), result, err})
+	}
+	for j := range bEntries {
+		if !matched[j] {
+			results = append(results, unmatchedEntry(&bEntries[j], Added))
+		}
+	}
+	return results, nil
+}
+
+//CompareHARWithURL compares the captured responses of the HAR file as the a side with the live responses of the
+//same requests sent to the base url as the b side. Requests are sent concurrently by the batch workers with their
+//captured methods and bodies, the side request of the b side applies to them otherwise. Entries without captured
+//responses get empty results.
+func (c *Comparator) CompareHARWithURL(ctx context.Context, path, bBase string) ([]HARResult, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	entries, err := LoadHAR(path)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]HARResult, len(entries))
+	c.forEach(ctx, len(entries), func(i int) {
+		entry := &entries[i]
+		request := c.bRequest
+		request.Method = entry.Method
+		request.Body = entry.Body
+		results[i] = HARResult{Method: entry.Method, Path: harPath(entry.URL)}
+		if entry.Response == nil {
+			results[i].Result = &Result{}
+			return
+		}
+		results[i].Result, results[i].Err = c.compareSnapshot(ctx, entry.Response, joinURL(bBase, results[i].Path),
+			&request, nil)
+	}, func(i int) {
+		results[i] = HARResult{Method: entries[i].Method, Path: harPath(entries[i].URL), Err: ctx.Err()}
+	})
+	return results, nil
+}
+
+//compareEntries compares the captured responses, a missing response is reported as a status change.
+func (c *Comparator) compareEntries(ctx context.Context, aResponse, bResponse *Snapshot) (*Result, error) {
+	if aResponse == nil || bResponse == nil {
+		result := &Result{}
+		if aResponse != bResponse {
+			aStatus, bStatus := snapshotStatus(aResponse), snapshotStatus(bResponse)
+			result.add(Change{"status", Modified, aStatus, bStatus}, Diff{aStatus, Delete}, Diff{bStatus, Insert})
+		}
+		return result, nil
+	}
+	result, err := c.compareResponses(ctx, aResponse.response(), nil, bResponse.response(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.postprocess(result)
+	return result, nil
+}
+
+func snapshotStatus(snapshot *Snapshot) string {
+	if snapshot == nil {
+		return "no response"
+	}
+	return snapshot.response().Status
+}
+
+//unmatchedEntry reports the entry captured by one of the files only.
+func unmatchedEntry(entry *HAREntry, kind ChangeKind) HARResult {
+	key := entry.key()
+	change := Change{Path: "entry", Kind: kind}
+	diff := Diff{key, Insert}
+	if kind == Removed {
+		change.Old = key
+		diff.Type = Delete
+	} else {
+		change.New = key
+	}
+	result := &Result{}
+	result.add(change, diff)
+	return HARResult{entry.Method, harPath(entry.URL), result, nil}
+}
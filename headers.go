@@ -0,0 +1,79 @@
+package comparator
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//compareStatuses reports different status codes of the responses.
+func compareStatuses(aResp, bResp *http.Response) *Result {
+	result := &Result{}
+	if aResp.StatusCode != bResp.StatusCode {
+		result.add(Change{"status", Modified, aResp.StatusCode, bResp.StatusCode},
+			Diff{"Status: " + statusText(aResp), Delete},
+			Diff{"Status: " + statusText(bResp), Insert})
+	}
+	return result
+}
+
+func statusText(resp *http.Response) string {
+	if resp.Status != "" {
+		return resp.Status
+	}
+	return strconv.Itoa(resp.StatusCode)
+}
+
+//headerDiffs reports headers with different values as a pair of diffs in the "Name: value" form. Headers
+//present in only one of the responses are reported by a single diff.
+func (c *Comparator) headerDiffs(aResp, bResp *http.Response) *Result {
+	result := &Result{}
+	for _, name := range c.comparedHeaders(aResp.Header, bResp.Header) {
+		aValues, aOK := aResp.Header[name]
+		bValues, bOK := bResp.Header[name]
+		aValue := strings.Join(aValues, ", ")
+		bValue := strings.Join(bValues, ", ")
+		if aOK == bOK && aValue == bValue {
+			continue
+		}
+		change := Change{Path: "header/" + name, Kind: changeKind(aOK, bOK)}
+		var diffs []Diff
+		if aOK {
+			change.Old = aValue
+			diffs = append(diffs, Diff{name + ": " + aValue, Delete})
+		}
+		if bOK {
+			change.New = bValue
+			diffs = append(diffs, Diff{name + ": " + bValue, Insert})
+		}
+		result.add(change, diffs...)
+	}
+	return result
+}
+
+//comparedHeaders returns sorted canonical names of the headers to compare. These are the ones from the allow list
+//if it is set or all the headers of both responses otherwise. Content-Type is always compared, ignored headers
+//are never compared.
+func (c *Comparator) comparedHeaders(aHeader, bHeader http.Header) []string {
+	names := map[string]bool{"Content-Type": true}
+	if len(c.headers) > 0 {
+		for _, name := range c.headers {
+			names[name] = true
+		}
+	} else {
+		for _, header := range []http.Header{aHeader, bHeader} {
+			for name := range header {
+				names[http.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+	var result []string
+	for name := range names {
+		if !c.ignoredHeaders[name] {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,99 @@
+//Package headless renders pages by headless chrome driven by chromedp, so that client rendered pages are compared
+//after their scripts run:
+//
+//	renderer, err := headless.New()
+//	if err != nil {
+//		return err
+//	}
+//	defer renderer.Close()
+//	c := comparator.New(comparator.WithRenderer(renderer))
+package headless
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+//Renderer renders pages in tabs of a single browser. It is safe for concurrent use.
+type Renderer struct {
+	//WaitSelector is the css selector of the element that has to be ready before the page is captured, the body by
+	//default.
+	WaitSelector string
+	//Delay is waited after the element is ready for the scripts that render late.
+	Delay   time.Duration
+	browser context.Context
+	cancel  context.CancelFunc
+}
+
+//New starts the browser with the default options followed by the options, like chromedp.ExecPath.
+func New(options ...chromedp.ExecAllocatorOption) (*Renderer, error) {
+	allocator, cancelAllocator := chromedp.NewExecAllocator(context.Background(),
+		append(chromedp.DefaultExecAllocatorOptions[:], options...)...)
+	browser, cancelBrowser := chromedp.NewContext(allocator)
+	if err := chromedp.Run(browser); err != nil {
+		cancelBrowser()
+		cancelAllocator()
+		return nil, fmt.Errorf("headless: start browser: %v", err)
+	}
+	return &Renderer{browser: browser, cancel: func() {
+		cancelBrowser()
+		cancelAllocator()
+	}}, nil
+}
+
+//Close stops the browser.
+func (r *Renderer) Close() {
+	r.cancel()
+}
+
+//Render opens the url of the request in a new tab and captures the rendered document. Headers of the request,
+//including its cookies and authentication, are sent with every request of the page. Only GET requests can be
+//rendered.
+func (r *Renderer) Render(ctx context.Context, req *http.Request) ([]byte, error) {
+	if req.Method != "" && req.Method != http.MethodGet {
+		return nil, fmt.Errorf("headless: %s requests can't be rendered", req.Method)
+	}
+	tab, cancel := chromedp.NewContext(r.browser)
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-done:
+		}
+	}()
+	headers := network.Headers{}
+	for name, values := range req.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	waitSelector := r.WaitSelector
+	if waitSelector == "" {
+		waitSelector = "body"
+	}
+	actions := []chromedp.Action{
+		network.Enable(),
+		network.SetExtraHTTPHeaders(headers),
+		chromedp.Navigate(req.URL.String()),
+		chromedp.WaitReady(waitSelector, chromedp.ByQuery),
+	}
+	if r.Delay > 0 {
+		actions = append(actions, chromedp.Sleep(r.Delay))
+	}
+	var page string
+	actions = append(actions, chromedp.OuterHTML("html", &page, chromedp.ByQuery))
+	if err := chromedp.Run(tab, actions...); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("headless: render %s: %v", req.URL, err)
+	}
+	return []byte(page), nil
+}
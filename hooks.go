@@ -0,0 +1,83 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+//RequestHook mutates requests of both sides before they are sent, for example to sign them or to add tracing
+//headers. It is called for every attempt of every request after the side authentication and must be safe for
+//concurrent use.
+type RequestHook interface {
+	BeforeRequest(req *http.Request) error
+}
+
+//RequestHookFunc adapts a function to the RequestHook interface.
+type RequestHookFunc func(req *http.Request) error
+
+//BeforeRequest calls f(req).
+func (f RequestHookFunc) BeforeRequest(req *http.Request) error {
+	return f(req)
+}
+
+//ResponseHook mutates responses of both sides before they are compared, for example to strip dynamic fields from
+//the body by replacing it. The body is already decompressed. It is called once per response after retries and must
+//be safe for concurrent use. Captured streams of messages are not passed to response hooks.
+type ResponseHook interface {
+	AfterResponse(resp *http.Response) error
+}
+
+//ResponseHookFunc adapts a function to the ResponseHook interface.
+type ResponseHookFunc func(resp *http.Response) error
+
+//AfterResponse calls f(resp).
+func (f ResponseHookFunc) AfterResponse(resp *http.Response) error {
+	return f(resp)
+}
+
+//DiffHook filters or rewrites the differences of a finished comparison before they are returned. The structured
+//changes and the flat diffs are passed separately since the flat diffs aren't linked to the changes.
+type DiffHook interface {
+	FilterDiffs(changes []Change, diffs []Diff) ([]Change, []Diff)
+}
+
+//DiffHookFunc adapts a function to the DiffHook interface.
+type DiffHookFunc func(changes []Change, diffs []Diff) ([]Change, []Diff)
+
+//FilterDiffs calls f(changes, diffs).
+func (f DiffHookFunc) FilterDiffs(changes []Change, diffs []Diff) ([]Change, []Diff) {
+	return f(changes, diffs)
+}
+
+//newRequest builds http request of the side and applies the request hooks to it.
+func (c *Comparator) newRequest(ctx context.Context, url string, request *Request) (*http.Request, error) {
+	req, err := request.newHTTPRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	for i, hook := range c.requestHooks {
+		if err := hook.BeforeRequest(req); err != nil {
+			return nil, fmt.Errorf("request hook %d %s: %w", i, url, err)
+		}
+	}
+	return req, nil
+}
+
+//afterResponse applies the response hooks to the response closing it if any of them fails.
+func (c *Comparator) afterResponse(resp *http.Response) error {
+	for i, hook := range c.responseHooks {
+		if err := hook.AfterResponse(resp); err != nil {
+			resp.Body.Close()
+			return fmt.Errorf("response hook %d %s: %w", i, responseURL(resp), err)
+		}
+	}
+	return nil
+}
+
+//filterDiffs applies the diff hooks to the result.
+func (c *Comparator) filterDiffs(result *Result) {
+	for _, hook := range c.diffHooks {
+		result.Changes, result.diffs = hook.FilterDiffs(result.Changes, result.diffs)
+	}
+}
@@ -0,0 +1,42 @@
+package comparator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFailingHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1}`)
+	}))
+	defer server.Close()
+	errUnsigned := errors.New("unsigned")
+	failingRequests := RequestHookFunc(func(req *http.Request) error {
+		if req.URL.Path == "/b" {
+			return errUnsigned
+		}
+		return nil
+	})
+	failingResponses := ResponseHookFunc(func(resp *http.Response) error {
+		if resp.Request.URL.Path == "/b" {
+			return errUnsigned
+		}
+		return nil
+	})
+	for name, option := range map[string]Option{
+		"request":  WithRequestHooks(failingRequests),
+		"response": WithResponseHooks(failingResponses),
+	} {
+		result, err := New(option).CompareResult(server.URL+"/a", server.URL+"/b", nil)
+		if err != nil {
+			t.Fatalf("%s hook: CompareResult returned error %v", name, err)
+		}
+		errs := result.FetchErrors()
+		if len(errs) != 1 || errs[0].Side != SideB || !errors.Is(errs[0], errUnsigned) {
+			t.Errorf("%s hook: unexpected fetch errors %v", name, errs)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+//xpathPrefix marks xpath expressions among the css selectors.
+const xpathPrefix = "xpath:"
+
+//compareHTMLs compares text of the selected elements and their element trees if enabled. An attribute of the
+//elements is compared instead of their text if the selector ends with "@name", like
+//"meta[name=description]@content". Elements are selected by xpath expressions prefixed by "xpath:" too, like
+//"xpath://meta[@name='description']/@content". Selector pairs, like ".old-price => [data-testid=price]", select
+//the elements differently on each side. Changes are located by the selectors.
+func (c *Comparator) compareHTMLs(ctx context.Context, aBody, bBody []byte, compareElements []string) (*Result,
+	error) {
+	result := &Result{}
+	aDoc, err := goquery.NewDocumentFromReader(bytes.NewReader(aBody))
+	if err != nil {
+		return nil, &ParseError{SideA, ModeHTML, err}
+	}
+	bDoc, err := goquery.NewDocumentFromReader(bytes.NewReader(bBody))
+	if err != nil {
+		return nil, &ParseError{SideB, ModeHTML, err}
+	}
+	for _, doc := range []*goquery.Document{aDoc, bDoc} {
+		if err := c.maskDocument(doc); err != nil {
+			return nil, err
+		}
+	}
+	if compareElements == nil {
+		compareElements = []string{"html"}
+	}
+	for _, element := range compareElements {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		aElement, bElement, aAttribute, bAttribute, err := c.selectElements(aDoc, bDoc, element)
+		if err != nil {
+			return nil, err
+		}
+		if aAttribute != "" {
+			c.compareAttributeValues(result, element, aAttribute, bAttribute, aElement, bElement)
+			continue
+		}
+		aText := c.elementText(aElement)
+		bText := c.elementText(bElement)
+		similar := aElement.Length() == bElement.Length() && c.similarText(element, aText, bText)
+		if (aText != bText || aElement.Length() != bElement.Length()) && !similar {
+			kind := changeKind(aElement.Length() > 0, bElement.Length() > 0)
+			result.add(Change{element, kind, aText, bText}, c.compareText(aText, bText)...)
+		}
+		if c.htmlStructure {
+			c.compareStructures(result, element, aElement.Nodes, bElement.Nodes)
+		}
+	}
+	return result, nil
+}
+
+//selectElements finds the elements selected by the css selector or the xpath expression in both documents, or by
+//the selectors of the pair on their sides. The attributes to compare are returned for css selectors with the
+//attribute suffix, xpath expressions select attributes themselves.
+func (c *Comparator) selectElements(aDoc, bDoc *goquery.Document, element string) (aElement,
+	bElement *goquery.Selection, aAttribute, bAttribute string, err error) {
+	aSelector, bSelector, ok := splitSelectorPair(element)
+	if !ok {
+		aSelector, bSelector = element, element
+	}
+	if aElement, aAttribute, err = c.selectElement(aDoc, aSelector); err != nil {
+		return nil, nil, "", "", err
+	}
+	if bElement, bAttribute, err = c.selectElement(bDoc, bSelector); err != nil {
+		return nil, nil, "", "", err
+	}
+	if (aAttribute == "") != (bAttribute == "") {
+		return nil, nil, "", "", fmt.Errorf("selector pair %s: either both or none of the selectors must select "+
+			"an attribute", element)
+	}
+	return aElement, bElement, aAttribute, bAttribute, nil
+}
+
+//selectElement finds the elements selected by the css selector or the xpath expression in the document.
+func (c *Comparator) selectElement(doc *goquery.Document, element string) (*goquery.Selection, string, error) {
+	if expression, ok := c.xpathExpression(element); ok {
+		expr, err := c.selectors.compileXPath(expression)
+		if err != nil {
+			return nil, "", err
+		}
+		return new(goquery.Selection).AddNodes(htmlquery.QuerySelectorAll(doc.Get(0), expr)...), "", nil
+	}
+	query, attribute := splitAttribute(element)
+	selector, err := c.selectors.compile(query)
+	if err != nil {
+		return nil, "", err
+	}
+	return doc.FindMatcher(selector), attribute, nil
+}
+
+//SelectorPair locates equivalent elements differently in both documents, like the price renamed by a redesign from
+//".old-price" to "[data-testid=price]". Either selector may be an xpath expression, if one selects an attribute by
+//the suffix the other has to as well. The pair is compared among the elements by its string form, which also fits
+//command line flags and config files.
+type SelectorPair struct {
+	A string
+	B string
+}
+
+//selectorPairSeparator separates the selectors of the sides in the string form of selector pairs.
+const selectorPairSeparator = " => "
+
+//String renders the pair as the compared element, like ".old-price => [data-testid=price]", which locates its
+//changes too.
+func (p SelectorPair) String() string {
+	return p.A + selectorPairSeparator + p.B
+}
+
+//splitSelectorPair separates the selectors of the sides of the element if it is a selector pair.
+func splitSelectorPair(element string) (string, string, bool) {
+	i := strings.Index(element, selectorPairSeparator)
+	if i < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(element[:i]), strings.TrimSpace(element[i+len(selectorPairSeparator):]), true
+}
+
+//xpathExpression reports whether the element is an xpath expression, either prefixed by "xpath:" or with xpath
+//selectors enabled.
+func (c *Comparator) xpathExpression(element string) (string, bool) {
+	if strings.HasPrefix(element, xpathPrefix) {
+		return strings.TrimPrefix(element, xpathPrefix), true
+	}
+	return element, c.xpathSelectors
+}
+
+//splitAttribute separates the attribute name suffix from the selector. The at sign may also appear in attribute
+//selectors, so only the one after the last closing bracket counts.
+func splitAttribute(element string) (string, string) {
+	i := strings.LastIndex(element, "@")
+	if i < 0 || i < strings.LastIndex(element, "]") {
+		return element, ""
+	}
+	return strings.TrimSpace(element[:i]), strings.TrimSpace(element[i+1:])
+}
+
+//compareAttributeValues compares values of the attributes of the selected elements, the attributes of selector
+//pairs may be named differently. Values of several elements are compared line by line, elements without the
+//attribute are skipped.
+func (c *Comparator) compareAttributeValues(result *Result, element, aAttribute, bAttribute string, aElement,
+	bElement *goquery.Selection) {
+	aValues := attributeOf(aElement, aAttribute)
+	bValues := attributeOf(bElement, bAttribute)
+	aText := c.normalize(strings.Join(aValues, "\n"))
+	bText := c.normalize(strings.Join(bValues, "\n"))
+	if aText != bText || len(aValues) != len(bValues) {
+		kind := changeKind(len(aValues) > 0, len(bValues) > 0)
+		result.add(Change{element, kind, aText, bText}, c.compareText(aText, bText)...)
+	}
+}
+
+func attributeOf(selection *goquery.Selection, attribute string) []string {
+	var values []string
+	selection.Each(func(_ int, element *goquery.Selection) {
+		if value, ok := element.Attr(attribute); ok {
+			values = append(values, value)
+		}
+	})
+	return values
+}
+
+//elementText extracts comparable text of the selected elements.
+func (c *Comparator) elementText(selection *goquery.Selection) string {
+	if !c.visibleTextOnly {
+		return c.normalize(selection.Text())
+	}
+	var buf bytes.Buffer
+	for _, node := range selection.Nodes {
+		writeVisibleText(&buf, node)
+	}
+	return c.normalize(buf.String())
+}
+
+//writeVisibleText writes text of the node skipping elements hidden by inline hints. Stylesheets are not
+//evaluated, so elements hidden by css rules are still included.
+func writeVisibleText(buf *bytes.Buffer, node *html.Node) {
+	if node.Type == html.TextNode {
+		buf.WriteString(node.Data)
+		return
+	}
+	if node.Type == html.ElementNode && isHidden(node) {
+		return
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeVisibleText(buf, child)
+	}
+}
+
+//isHidden reports whether the element is hidden by the hidden or aria-hidden attributes or by the inline style.
+func isHidden(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if strings.EqualFold(strings.TrimSpace(attr.Val), "true") {
+				return true
+			}
+		case "style":
+			if hiddenByStyle(attr.Val) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hiddenByStyle(style string) bool {
+	for _, declaration := range strings.Split(style, ";") {
+		parts := strings.SplitN(declaration, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		property := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.ToLower(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(parts[1]), "!important")))
+		if property == "display" && value == "none" || property == "visibility" && value == "hidden" {
+			return true
+		}
+	}
+	return false
+}
+
+//compareStructures compares element trees of the nodes matched by the selector. Several matched nodes are located
+//by the selector and their index like "li[1]", their descendants by css child selectors like
+//"ul > li:nth-child(2)" and attributes by the "@name" suffix. Nodes matched on one side only are already reported
+//by the text comparison.
+func (c *Comparator) compareStructures(result *Result, element string, aNodes, bNodes []*html.Node) {
+	for i := 0; i < len(aNodes) && i < len(bNodes); i++ {
+		path := element
+		if len(aNodes) > 1 || len(bNodes) > 1 {
+			path = fmt.Sprintf("%s[%d]", element, i)
+		}
+		c.compareNodes(result, path, aNodes[i], bNodes[i])
+	}
+}
+
+//compareNodes compares tags, attributes and child elements of the nodes. Children are paired by their position.
+func (c *Comparator) compareNodes(result *Result, path string, a, b *html.Node) {
+	if a.Data != b.Data {
+		result.add(Change{path, Modified, a.Data, b.Data},
+			Diff{"<" + a.Data + ">", Delete}, Diff{"<" + b.Data + ">", Insert})
+		return
+	}
+	compareAttributes(result, path, a.Attr, b.Attr)
+	aChildren := c.childElements(a)
+	bChildren := c.childElements(b)
+	for i := 0; i < len(aChildren) || i < len(bChildren); i++ {
+		switch {
+		case i >= len(aChildren):
+			child := bChildren[i]
+			result.add(Change{Path: childSelector(path, child.Data, i), Kind: Added, New: renderNode(child)},
+				Diff{renderNode(child), Insert})
+		case i >= len(bChildren):
+			child := aChildren[i]
+			result.add(Change{Path: childSelector(path, child.Data, i), Kind: Removed, Old: renderNode(child)},
+				Diff{renderNode(child), Delete})
+		default:
+			c.compareNodes(result, childSelector(path, aChildren[i].Data, i), aChildren[i], bChildren[i])
+		}
+	}
+}
+
+//compareAttributes reports attributes added, removed or changed in the element in the order of their names.
+func compareAttributes(result *Result, path string, aAttrs, bAttrs []html.Attribute) {
+	aValues := attributeValues(aAttrs)
+	bValues := attributeValues(bAttrs)
+	names := make([]string, 0, len(aValues)+len(bValues))
+	for name := range aValues {
+		names = append(names, name)
+	}
+	for name := range bValues {
+		if _, ok := aValues[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		aValue, aOK := aValues[name]
+		bValue, bOK := bValues[name]
+		if aOK && bOK && aValue == bValue {
+			continue
+		}
+		change := Change{Path: path + "@" + name, Kind: changeKind(aOK, bOK)}
+		var diffs []Diff
+		if aOK {
+			change.Old = aValue
+			diffs = append(diffs, Diff{fmt.Sprintf("%s=%q", name, aValue), Delete})
+		}
+		if bOK {
+			change.New = bValue
+			diffs = append(diffs, Diff{fmt.Sprintf("%s=%q", name, bValue), Insert})
+		}
+		result.add(change, diffs...)
+	}
+}
+
+func attributeValues(attrs []html.Attribute) map[string]string {
+	values := make(map[string]string, len(attrs))
+	for _, attr := range attrs {
+		values[attr.Key] = attr.Val
+	}
+	return values
+}
+
+//childElements returns element children of the node skipping the hidden ones if only visible text is compared.
+func (c *Comparator) childElements(node *html.Node) []*html.Node {
+	var children []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || c.visibleTextOnly && isHidden(child) {
+			continue
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+func childSelector(path, tag string, index int) string {
+	return fmt.Sprintf("%s > %s:nth-child(%d)", path, tag, index+1)
+}
+
+func renderNode(node *html.Node) string {
+	var buf bytes.Buffer
+	html.Render(&buf, node)
+	return buf.String()
+}
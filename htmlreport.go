@@ -0,0 +1,101 @@
+package comparator
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+//htmlReport is the page of the html report. Deleted text is highlighted on the left side, inserted on the right.
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Comparison report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.4em; text-align: left; vertical-align: top; }
+td.value { font-family: monospace; white-space: pre-wrap; width: 40%; }
+del { background: #fdd; color: #a00; text-decoration: none; }
+ins { background: #dfd; color: #060; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>Comparison report</h1>
+{{with .Report}}<table>
+<tr><th></th><th>URL</th><th>Status</th><th>Latency, ms</th><th>Error</th></tr>
+<tr><th>A</th><td>{{.A.URL}}</td><td>{{.A.Status}}</td><td>{{printf "%.1f" .A.LatencyMS}}</td><td>{{.A.Error}}</td></tr>
+<tr><th>B</th><td>{{.B.URL}}</td><td>{{.B.Status}}</td><td>{{printf "%.1f" .B.LatencyMS}}</td><td>{{.B.Error}}</td></tr>
+</table>
+<p>Started {{.Started.Format "2006-01-02 15:04:05.000 MST"}},
+finished {{.Finished.Format "2006-01-02 15:04:05.000 MST"}}.
+{{with .Error}}<strong>{{.}}</strong>{{end}}</p>
+{{end}}{{if .Rows}}<table>
+<tr><th>Path</th><th>Kind</th><th>A</th><th>B</th></tr>
+{{range .Rows}}<tr><td>{{.Path}}</td><td>{{.Kind}}</td>
+<td class="value">{{.Old}}</td><td class="value">{{.New}}</td></tr>
+{{end}}</table>{{else}}<p>No differences.</p>{{end}}
+</body>
+</html>
+`))
+
+type htmlReportData struct {
+	Report *Report
+	Rows   []htmlReportRow
+}
+
+type htmlReportRow struct {
+	Path string
+	Kind ChangeKind
+	Old  template.HTML
+	New  template.HTML
+}
+
+//WriteHTML renders the changes as an html page with the differences of the values highlighted side by side.
+func (r *Result) WriteHTML(w io.Writer) error {
+	return writeHTMLReport(w, nil, r.Changes)
+}
+
+//WriteHTML renders the report as an html page with the differences of the values highlighted side by side.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return writeHTMLReport(w, r, r.Changes)
+}
+
+func writeHTMLReport(w io.Writer, report *Report, changes []Change) error {
+	data := htmlReportData{Report: report}
+	for _, change := range changes {
+		aText, err := unifiedText(change.Old)
+		if err != nil {
+			return err
+		}
+		bText, err := unifiedText(change.New)
+		if err != nil {
+			return err
+		}
+		aHTML, bHTML := highlightDiffs(aText, bText)
+		data.Rows = append(data.Rows, htmlReportRow{change.Path, change.Kind, aHTML, bHTML})
+	}
+	return htmlReport.Execute(w, data)
+}
+
+//highlightDiffs renders both texts escaped for html with the deleted and inserted parts wrapped in del and ins.
+func highlightDiffs(aText, bText string) (template.HTML, template.HTML) {
+	diffs := textDiffer.DiffCleanupSemantic(textDiffer.DiffMain(aText, bText, true))
+	var aHTML, bHTML strings.Builder
+	for _, diff := range diffs {
+		text := template.HTMLEscapeString(diff.Text)
+		switch diff.Type {
+		case diffmatchpatch.DiffEqual:
+			aHTML.WriteString(text)
+			bHTML.WriteString(text)
+		case diffmatchpatch.DiffDelete:
+			aHTML.WriteString("<del>" + text + "</del>")
+		case diffmatchpatch.DiffInsert:
+			bHTML.WriteString("<ins>" + text + "</ins>")
+		}
+	}
+	return template.HTML(aHTML.String()), template.HTML(bHTML.String())
+}
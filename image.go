@@ -0,0 +1,83 @@
+package comparator
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"  //register gif decoder
+	_ "image/jpeg" //register jpeg decoder
+	"image/png"
+)
+
+//highlightColor marks the differing pixels of the diff image.
+var highlightColor = color.RGBA{R: 0xff, A: 0xff}
+
+//compareImages decodes both images and compares them pixel by pixel. Bodies that are not decodable images are
+//compared as binary. The pixels outside of the smaller image count as differing ones. The percentage of the
+//differing pixels is the New value of the "image/pixels" change.
+func (c *Comparator) compareImages(aBody, bBody []byte) (*Result, error) {
+	aImage, _, aErr := image.Decode(bytes.NewReader(aBody))
+	bImage, _, bErr := image.Decode(bytes.NewReader(bBody))
+	if aErr != nil || bErr != nil {
+		return compareBinaries(aBody, bBody), nil
+	}
+	result := &Result{}
+	aBounds := aImage.Bounds()
+	bBounds := bImage.Bounds()
+	if aBounds.Dx() != bBounds.Dx() || aBounds.Dy() != bBounds.Dy() {
+		aSize := fmt.Sprintf("%dx%d", aBounds.Dx(), aBounds.Dy())
+		bSize := fmt.Sprintf("%dx%d", bBounds.Dx(), bBounds.Dy())
+		result.add(Change{"image/size", Modified, aSize, bSize},
+			Diff{"Size: " + aSize, Delete}, Diff{"Size: " + bSize, Insert})
+	}
+	width, height := aBounds.Dx(), aBounds.Dy()
+	if bBounds.Dx() > width {
+		width = bBounds.Dx()
+	}
+	if bBounds.Dy() > height {
+		height = bBounds.Dy()
+	}
+	var highlighted *image.RGBA
+	if c.imageDiff != nil {
+		highlighted = image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(highlighted, highlighted.Bounds(), aImage, aBounds.Min, draw.Src)
+	}
+	differing := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if equalPixels(aImage, bImage, x, y) {
+				continue
+			}
+			differing++
+			if highlighted != nil {
+				highlighted.Set(x, y, highlightColor)
+			}
+		}
+	}
+	if differing == 0 {
+		return result, nil
+	}
+	percentage := float64(differing) * 100 / float64(width*height)
+	result.add(Change{Path: "image/pixels", Kind: Modified, New: percentage},
+		Diff{fmt.Sprintf("Pixels: %d of %d (%.2f%%) differ", differing, width*height, percentage), Insert})
+	if highlighted != nil {
+		if err := png.Encode(c.imageDiff, highlighted); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+//equalPixels compares pixels of the images at the offset from their top left corners.
+func equalPixels(a, b image.Image, x, y int) bool {
+	aPoint := a.Bounds().Min.Add(image.Pt(x, y))
+	bPoint := b.Bounds().Min.Add(image.Pt(x, y))
+	if !aPoint.In(a.Bounds()) || !bPoint.In(b.Bounds()) {
+		return false
+	}
+	aR, aG, aB, aA := a.At(aPoint.X, aPoint.Y).RGBA()
+	bR, bG, bB, bA := b.At(bPoint.X, bPoint.Y).RGBA()
+	return aR == bR && aG == bG && aB == bB && aA == bA
+}
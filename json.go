@@ -0,0 +1,241 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/yudai/gojsondiff"
+	"github.com/yudai/gojsondiff/formatter"
+)
+
+//CompareJSON compares the json documents or streams structurally regardless of the mode. Json options apply as for
+//json responses.
+func (c *Comparator) CompareJSON(a, b []byte) (*Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	result, err := c.compareJSONs(context.Background(), a, b)
+	if err != nil {
+		return nil, err
+	}
+	c.postprocess(result)
+	return result, nil
+}
+
+//compareJSONs compares json bodies. Values of concatenated json streams are compared positionally and addressed
+//by their position, like /1/items/3, a single document is addressed from its root.
+func (c *Comparator) compareJSONs(ctx context.Context, aBody, bBody []byte) (*Result, error) {
+	aValues, aRest, err := decodeJSONStream(aBody)
+	if err != nil {
+		return nil, &ParseError{SideA, ModeJSON, err}
+	}
+	bValues, bRest, err := decodeJSONStream(bBody)
+	if err != nil {
+		return nil, &ParseError{SideB, ModeJSON, err}
+	}
+	return c.compareTrees(ctx, aValues, bValues, aRest, bRest)
+}
+
+//compareTrees compares successive values decoded into the json tree form. Trailing texts that follow the last
+//values are compared as text. Json ignore rules, masks, array keys, tolerances and similarity thresholds apply to
+//every tree.
+func (c *Comparator) compareTrees(ctx context.Context, aValues, bValues []interface{}, aRest, bRest string) (
+	*Result, error) {
+	var err error
+	for _, values := range [][]interface{}{aValues, bValues} {
+		for i, value := range values {
+			for _, path := range c.ignoredPaths {
+				path.strip(value)
+			}
+			if len(c.masks) > 0 {
+				value = c.maskTree(value)
+				values[i] = value
+			}
+			if len(c.arrayKeys) > 0 {
+				values[i] = c.keyArrays(nil, value)
+			}
+		}
+	}
+	result := &Result{}
+	stream := len(aValues) > 1 || len(bValues) > 1 || aRest != bRest
+	for i := 0; i < len(aValues) || i < len(bValues); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		prefix := ""
+		if stream {
+			prefix = "/" + strconv.Itoa(i)
+		}
+		switch {
+		case i >= len(aValues):
+			err = addJSONValue(result, prefix, bValues[i], Added)
+		case i >= len(bValues):
+			err = addJSONValue(result, prefix, aValues[i], Removed)
+		default:
+			bValue := bValues[i]
+			if len(c.similarities) > 0 {
+				bValue = c.equalizeSimilar(nil, aValues[i], bValue)
+			}
+			err = compareJSONValues(result, prefix, aValues[i], c.alignJSON(aValues[i], bValue))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if aRest != bRest {
+		last := len(aValues)
+		if len(bValues) > last {
+			last = len(bValues)
+		}
+		change := Change{"/" + strconv.Itoa(last), changeKind(aRest != "", bRest != ""), aRest, bRest}
+		result.add(change, c.compareText(aRest, bRest)...)
+	}
+	return result, nil
+}
+
+//decodeJSONStream reads successive json values from the body, so concatenated objects without any framing are
+//supported as well as a single document. Anything that follows the last valid value is returned as the trailing
+//text instead of failing the whole comparison. The body must start with a valid json value.
+func decodeJSONStream(body []byte) ([]interface{}, string, error) {
+	var values []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	for {
+		offset := decoder.InputOffset()
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			return values, "", nil
+		}
+		if err != nil {
+			if len(values) == 0 {
+				return nil, "", err
+			}
+			return values, strings.TrimSpace(string(body[offset:])), nil
+		}
+		values = append(values, value)
+	}
+}
+
+//compareJSONValues compares two json values at the same position of the streams. Objects are compared field by
+//field, other values are rendered as a whole in the flat form. Changes within arrays are still located
+//structurally.
+func compareJSONValues(result *Result, prefix string, aValue, bValue interface{}) error {
+	aJSON, aOK := aValue.(map[string]interface{})
+	bJSON, bOK := bValue.(map[string]interface{})
+	if aOK && bOK {
+		diff := jsonDiffer.CompareObjects(aJSON, bJSON)
+		formatter := formatter.NewAsciiFormatter(aJSON)
+		diffString, err := formatter.Format(diff)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(diffString, "\n")
+		changes := Result{diffs: getDiffsFromStrings(lines)}
+		addDeltaChanges(&changes, prefix, diff.Deltas())
+		result.merge(&changes)
+		return nil
+	}
+	if reflect.DeepEqual(aValue, bValue) {
+		return nil
+	}
+	deleted, err := jsonValueDiff(aValue, Delete)
+	if err != nil {
+		return err
+	}
+	inserted, err := jsonValueDiff(bValue, Insert)
+	if err != nil {
+		return err
+	}
+	changes := Result{diffs: append(deleted, inserted...)}
+	aArray, aOK := aValue.([]interface{})
+	bArray, bOK := bValue.([]interface{})
+	if aOK && bOK {
+		addDeltaChanges(&changes, prefix, jsonDiffer.CompareArrays(aArray, bArray).Deltas())
+	} else {
+		changes.Changes = []Change{Change{prefix, Modified, aValue, bValue}}
+	}
+	result.merge(&changes)
+	return nil
+}
+
+//addJSONValue adds the whole value present at only one side.
+func addJSONValue(result *Result, path string, value interface{}, kind ChangeKind) error {
+	diffType := Insert
+	change := Change{path, kind, nil, value}
+	if kind == Removed {
+		diffType = Delete
+		change = Change{path, kind, value, nil}
+	}
+	diffs, err := jsonValueDiff(value, diffType)
+	if err != nil {
+		return err
+	}
+	result.add(change, diffs...)
+	return nil
+}
+
+//jsonValueDiff renders the whole value as a single diff of the provided type.
+func jsonValueDiff(value interface{}, diffType DiffType) ([]Diff, error) {
+	text, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return []Diff{Diff{string(text), diffType}}, nil
+}
+
+//addDeltaChanges converts gojsondiff deltas into structured changes. Removed array elements are addressed by
+//their index in the old array, added and modified ones by the index in the new array. Moved elements are
+//reported as removed from the old position and added to the new one.
+func addDeltaChanges(result *Result, prefix string, deltas []gojsondiff.Delta) {
+	for _, delta := range deltas {
+		switch d := delta.(type) {
+		case *gojsondiff.Object:
+			addDeltaChanges(result, jsonPointer(prefix, d.PostPosition()), d.Deltas)
+		case *gojsondiff.Array:
+			addDeltaChanges(result, jsonPointer(prefix, d.PostPosition()), d.Deltas)
+		case *gojsondiff.Added:
+			result.Changes = append(result.Changes, Change{jsonPointer(prefix, d.PostPosition()), Added, nil, d.Value})
+		case *gojsondiff.Deleted:
+			result.Changes = append(result.Changes,
+				Change{jsonPointer(prefix, d.PrePosition()), Removed, d.Value, nil})
+		case *gojsondiff.Modified:
+			result.Changes = append(result.Changes,
+				Change{jsonPointer(prefix, d.PostPosition()), Modified, d.OldValue, d.NewValue})
+		case *gojsondiff.TextDiff:
+			result.Changes = append(result.Changes,
+				Change{jsonPointer(prefix, d.PostPosition()), Modified, d.OldValue, d.NewValue})
+		case *gojsondiff.Moved:
+			result.Changes = append(result.Changes,
+				Change{jsonPointer(prefix, d.PrePosition()), Removed, d.Value, nil},
+				Change{jsonPointer(prefix, d.PostPosition()), Added, nil, d.Value})
+		}
+	}
+}
+
+//jsonPointer appends escaped reference token of the position to the pointer.
+func jsonPointer(prefix string, position gojsondiff.Position) string {
+	token := strings.Replace(position.String(), "~", "~0", -1)
+	token = strings.Replace(token, "/", "~1", -1)
+	return prefix + "/" + token
+}
+
+func getDiffsFromStrings(lines []string) []Diff {
+	var diffs []Diff
+	for _, line := range lines {
+		if strings.HasPrefix(line, "+") {
+			line = strings.Replace(line, "+", "", 1)
+			diffs = append(diffs, Diff{line, Insert})
+		} else if strings.HasPrefix(line, "-") {
+			line = strings.Replace(line, "-", "", 1)
+			diffs = append(diffs, Diff{line, Delete})
+		} else {
+			continue
+		}
+	}
+	return diffs
+}
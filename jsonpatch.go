@@ -0,0 +1,398 @@
+package comparator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//PatchOperation is an operation of a JSON Patch (RFC 6902).
+type PatchOperation struct {
+	//Op is one of add, remove, replace, move, copy and test.
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	//From is the source location of move and copy.
+	From string `json:"from,omitempty"`
+	//Value is the value of add, replace and test.
+	Value interface{} `json:"value,omitempty"`
+}
+
+//MarshalJSON renders the value of add, replace and test operations even if it is null.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	type operation PatchOperation
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			Op    string      `json:"op"`
+			Path  string      `json:"path"`
+			Value interface{} `json:"value"`
+		}{o.Op, o.Path, o.Value})
+	}
+	return json.Marshal(operation(o))
+}
+
+//JSONPatch returns the JSON Patch transforming the json document a into b with the default options.
+func JSONPatch(a, b []byte) ([]PatchOperation, error) {
+	return defaultComparator.JSONPatch(a, b)
+}
+
+//JSONPatch returns the JSON Patch transforming the json document a into b, so that ApplyPatch(a, patch) is equal
+//to b. Objects are patched member by member in the order of their names. Arrays are patched element by element
+//up to the shorter length, then their extra elements are removed from the end or added, so the patch is applicable
+//but not minimal for elements inserted or removed in the middle. Values excluded by WithIgnoredPaths are not
+//patched, other json options don't apply.
+func (c *Comparator) JSONPatch(a, b []byte) ([]PatchOperation, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	aValue, err := decodePatchDocument(a)
+	if err != nil {
+		return nil, &ParseError{SideA, ModeJSON, err}
+	}
+	bValue, err := decodePatchDocument(b)
+	if err != nil {
+		return nil, &ParseError{SideB, ModeJSON, err}
+	}
+	for _, path := range c.ignoredPaths {
+		path.strip(aValue)
+		path.strip(bValue)
+	}
+	patch := []PatchOperation{}
+	diffPatch(&patch, "", aValue, bValue)
+	return patch, nil
+}
+
+func diffPatch(patch *[]PatchOperation, pointer string, a, b interface{}) {
+	aObject, aOK := a.(map[string]interface{})
+	bObject, bOK := b.(map[string]interface{})
+	if aOK && bOK {
+		names := make([]string, 0, len(aObject)+len(bObject))
+		for name := range aObject {
+			names = append(names, name)
+		}
+		for name := range bObject {
+			if _, ok := aObject[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			aChild, aOK := aObject[name]
+			bChild, bOK := bObject[name]
+			path := pointer + "/" + escapePointerToken(name)
+			switch {
+			case !bOK:
+				*patch = append(*patch, PatchOperation{Op: "remove", Path: path})
+			case !aOK:
+				*patch = append(*patch, PatchOperation{Op: "add", Path: path, Value: bChild})
+			default:
+				diffPatch(patch, path, aChild, bChild)
+			}
+		}
+		return
+	}
+	aArray, aOK := a.([]interface{})
+	bArray, bOK := b.([]interface{})
+	if aOK && bOK {
+		common := len(aArray)
+		if len(bArray) < common {
+			common = len(bArray)
+		}
+		for i := 0; i < common; i++ {
+			diffPatch(patch, pointer+"/"+strconv.Itoa(i), aArray[i], bArray[i])
+		}
+		for i := len(aArray) - 1; i >= common; i-- {
+			*patch = append(*patch, PatchOperation{Op: "remove", Path: pointer + "/" + strconv.Itoa(i)})
+		}
+		for i := common; i < len(bArray); i++ {
+			*patch = append(*patch, PatchOperation{Op: "add", Path: pointer + "/-", Value: bArray[i]})
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*patch = append(*patch, PatchOperation{Op: "replace", Path: pointer, Value: b})
+	}
+}
+
+//ApplyPatch applies the JSON Patch to the json document and returns the patched document. Operations are applied
+//in order and the first failing one, including a failing test, fails the whole patch. Numbers are kept as written.
+func ApplyPatch(document []byte, patch []PatchOperation) ([]byte, error) {
+	value, err := decodePatchDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	for i, operation := range patch {
+		if value, err = operation.apply(value); err != nil {
+			return nil, fmt.Errorf("patch operation %d %s %s: %v", i, operation.Op, operation.Path, err)
+		}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+//decodePatchDocument decodes a single json document keeping its numbers as written.
+func decodePatchDocument(document []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after the json document")
+	}
+	return value, nil
+}
+
+//apply applies the operation to the document and returns the new root.
+func (o PatchOperation) apply(document interface{}) (interface{}, error) {
+	switch o.Op {
+	case "add":
+		return addPointer(document, o.Path, copyJSON(o.Value))
+	case "remove":
+		document, _, err := removePointer(document, o.Path)
+		return document, err
+	case "replace":
+		document, _, err := removePointer(document, o.Path)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(document, o.Path, copyJSON(o.Value))
+	case "move":
+		if o.Path != o.From && strings.HasPrefix(o.Path, o.From+"/") {
+			return nil, fmt.Errorf("can't move %s into itself", o.From)
+		}
+		document, value, err := removePointer(document, o.From)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(document, o.Path, value)
+	case "copy":
+		value, err := getPointer(document, o.From)
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(document, o.Path, copyJSON(value))
+	case "test":
+		value, err := getPointer(document, o.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(normalizeJSON(value), normalizeJSON(o.Value)) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return document, nil
+	}
+	return nil, fmt.Errorf("unknown operation")
+}
+
+//pointerTokens splits the JSON Pointer into its unescaped reference tokens.
+func pointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+func escapePointerToken(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+//arrayIndex parses the token as an index of the array, the end of the array is allowed if end is set.
+func arrayIndex(token string, array []interface{}, end bool) (int, error) {
+	if end && token == "-" {
+		return len(array), nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || token != strconv.Itoa(i) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > len(array) || i == len(array) && !end {
+		return 0, fmt.Errorf("array index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+func getPointer(document interface{}, pointer string) (interface{}, error) {
+	tokens, err := pointerTokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+	current := document
+	for _, token := range tokens {
+		switch parent := current.(type) {
+		case map[string]interface{}:
+			child, ok := parent[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			current = child
+		case []interface{}:
+			i, err := arrayIndex(token, parent, false)
+			if err != nil {
+				return nil, err
+			}
+			current = parent[i]
+		default:
+			return nil, fmt.Errorf("no container at %q", token)
+		}
+	}
+	return current, nil
+}
+
+//addPointer adds the value at the pointer and returns the new root, arrays are grown by inserting it.
+func addPointer(document interface{}, pointer string, value interface{}) (interface{}, error) {
+	tokens, err := pointerTokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(document, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case map[string]interface{}:
+			parent[token] = value
+			return parent, nil
+		case []interface{}:
+			i, err := arrayIndex(token, parent, true)
+			if err != nil {
+				return nil, err
+			}
+			parent = append(parent, nil)
+			copy(parent[i+1:], parent[i:])
+			parent[i] = value
+			return parent, nil
+		}
+		return nil, fmt.Errorf("no container at %q", pointer)
+	})
+}
+
+//removePointer removes the value at the pointer and returns the new root with the removed value.
+func removePointer(document interface{}, pointer string) (interface{}, interface{}, error) {
+	tokens, err := pointerTokens(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, document, nil
+	}
+	var removed interface{}
+	document, err = updateParent(document, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case map[string]interface{}:
+			child, ok := parent[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			removed = child
+			delete(parent, token)
+			return parent, nil
+		case []interface{}:
+			i, err := arrayIndex(token, parent, false)
+			if err != nil {
+				return nil, err
+			}
+			removed = parent[i]
+			return append(parent[:i], parent[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("no container at %q", pointer)
+	})
+	return document, removed, err
+}
+
+//updateParent replaces the parent of the last token by the result of update, so that arrays can change their
+//lengths, and returns the new root.
+func updateParent(document interface{}, tokens []string, update func(parent interface{},
+	token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return update(document, tokens[0])
+	}
+	switch parent := document.(type) {
+	case map[string]interface{}:
+		child, ok := parent[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", tokens[0])
+		}
+		updated, err := updateParent(child, tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		parent[tokens[0]] = updated
+		return parent, nil
+	case []interface{}:
+		i, err := arrayIndex(tokens[0], parent, false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateParent(parent[i], tokens[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		parent[i] = updated
+		return parent, nil
+	}
+	return nil, fmt.Errorf("no container at %q", tokens[0])
+}
+
+//copyJSON deep copies the json value, so that values added by a patch are not shared.
+func copyJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for name, child := range v {
+			copied[name] = copyJSON(child)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, child := range v {
+			copied[i] = copyJSON(child)
+		}
+		return copied
+	}
+	return value
+}
+
+//normalizeJSON renders numbers as float64, so that numbers decoded differently compare equal.
+func normalizeJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case int:
+		return float64(v)
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for name, child := range v {
+			normalized[name] = normalizeJSON(child)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, child := range v {
+			normalized[i] = normalizeJSON(child)
+		}
+		return normalized
+	}
+	return value
+}
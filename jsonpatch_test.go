@@ -0,0 +1,18 @@
+package comparator
+
+import "testing"
+
+func TestJSONPatchTrailingData(t *testing.T) {
+	for _, document := range []string{`{"a":1}}`, `[1]]`, `{"a":1} {"a":2}`, `{"a":1} x`} {
+		if _, err := JSONPatch([]byte(document), []byte(`{"a":1}`)); err == nil {
+			t.Errorf("JSONPatch accepted the trailing data of %s", document)
+		}
+	}
+	patch, err := JSONPatch([]byte("{\"a\":1}\n"), []byte(`{"a":2}`))
+	if err != nil {
+		t.Fatalf("JSONPatch returned error %v", err)
+	}
+	if len(patch) != 1 || patch[0].Op != "replace" || patch[0].Path != "/a" {
+		t.Errorf("unexpected patch %+v", patch)
+	}
+}
@@ -0,0 +1,194 @@
+package comparator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//jsonPath is a compiled JSONPath ($.meta.requestId, $.items[*].id, $..updatedAt) or JSON Pointer (/data/*/id)
+//pattern. Only child, wildcard and recursive descent selectors are supported.
+type jsonPath []pathSegment
+
+//pathSegment matches object members or array elements by name or index. Any name matches a wildcard segment. A
+//recursive segment matches at any depth.
+type pathSegment struct {
+	name      string
+	wildcard  bool
+	recursive bool
+}
+
+//compileJSONPath parses JSONPath pattern starting with $ or JSON Pointer pattern starting with /.
+func compileJSONPath(pattern string) (jsonPath, error) {
+	switch {
+	case strings.HasPrefix(pattern, "$"):
+		return compileDotPath(pattern)
+	case strings.HasPrefix(pattern, "/"):
+		return compilePointerPath(pattern), nil
+	}
+	return nil, fmt.Errorf("comparator: path %q must start with $ or /", pattern)
+}
+
+func compilePointerPath(pattern string) jsonPath {
+	var path jsonPath
+	for _, token := range strings.Split(pattern[1:], "/") {
+		token = strings.Replace(token, "~1", "/", -1)
+		token = strings.Replace(token, "~0", "~", -1)
+		path = append(path, pathSegment{name: token, wildcard: token == "*"})
+	}
+	return path
+}
+
+func compileDotPath(pattern string) (jsonPath, error) {
+	var path jsonPath
+	rest := pattern[1:]
+	for rest != "" {
+		segment := pathSegment{}
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			segment.recursive = true
+			rest = rest[2:]
+		case rest[0] == '.':
+			rest = rest[1:]
+		case rest[0] != '[':
+			return nil, fmt.Errorf("comparator: invalid path %q", pattern)
+		}
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("comparator: unclosed bracket in path %q", pattern)
+			}
+			name := rest[1:end]
+			if unquoted, err := strconv.Unquote(strings.Replace(name, "'", "\"", -1)); err == nil {
+				segment.name = unquoted
+			} else {
+				segment.name = name
+				segment.wildcard = name == "*"
+			}
+			rest = rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segment.name = rest[:end]
+			segment.wildcard = segment.name == "*"
+			rest = rest[end:]
+		}
+		if segment.name == "" && !segment.wildcard {
+			return nil, fmt.Errorf("comparator: empty segment in path %q", pattern)
+		}
+		path = append(path, segment)
+	}
+	return path, nil
+}
+
+func (s pathSegment) matches(name string) bool {
+	return s.wildcard || s.name == name
+}
+
+//strip removes matching object members and masks matching array elements with null, so that positions of the
+//remaining elements are kept. The value is modified in place.
+func (p jsonPath) strip(value interface{}) {
+	stripSegments(value, p)
+}
+
+func stripSegments(value interface{}, segments []pathSegment) {
+	if len(segments) == 0 {
+		return
+	}
+	segment := segments[0]
+	if segment.recursive {
+		direct := segment
+		direct.recursive = false
+		stripSegments(value, append([]pathSegment{direct}, segments[1:]...))
+		forEachChild(value, func(child interface{}) {
+			stripSegments(child, segments)
+		})
+		return
+	}
+	rest := segments[1:]
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if !segment.matches(name) {
+				continue
+			}
+			if len(rest) == 0 {
+				delete(v, name)
+			} else {
+				stripSegments(child, rest)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if !segment.matches(strconv.Itoa(i)) {
+				continue
+			}
+			if len(rest) == 0 {
+				v[i] = nil
+			} else {
+				stripSegments(child, rest)
+			}
+		}
+	}
+}
+
+//resolvePointer returns the value located by the JSON Pointer in the document, nil if there is none. The empty
+//pointer locates the whole document.
+func resolvePointer(document interface{}, pointer string) interface{} {
+	if pointer == "" {
+		return document
+	}
+	current := document
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch parent := current.(type) {
+		case map[string]interface{}:
+			current = parent[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(parent) {
+				return nil
+			}
+			current = parent[i]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+//match reports whether the path of reference tokens from the document root matches the pattern.
+func (p jsonPath) match(path []string) bool {
+	return matchSegments(p, path)
+}
+
+func matchSegments(segments []pathSegment, path []string) bool {
+	if len(segments) == 0 {
+		return len(path) == 0
+	}
+	segment := segments[0]
+	if segment.recursive {
+		for i := range path {
+			if segment.matches(path[i]) && matchSegments(segments[1:], path[i+1:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(path) > 0 && segment.matches(path[0]) && matchSegments(segments[1:], path[1:])
+}
+
+func forEachChild(value interface{}, f func(interface{})) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			f(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			f(child)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package comparator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+//JUnitCase is a comparison of urls or elements reported as a junit test case.
+type JUnitCase struct {
+	Name     string
+	Result   *Result
+	Err      error
+	Duration time.Duration
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     float64     `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name    string        `xml:"name,attr"`
+	Class   string        `xml:"classname,attr"`
+	Time    float64       `xml:"time,attr"`
+	Failure *junitMessage `xml:"failure,omitempty"`
+	Error   *junitMessage `xml:"error,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+//WriteJUnit writes the cases as a junit xml test suite. A case fails when it has more changes than the threshold
+//and errs when its comparison returned an error, so zero threshold fails on any difference.
+func WriteJUnit(w io.Writer, suite string, cases []JUnitCase, threshold int) error {
+	report := junitSuite{Name: suite, Tests: len(cases)}
+	for _, c := range cases {
+		junit := junitCase{Name: c.Name, Class: suite, Time: c.Duration.Seconds()}
+		report.Time += junit.Time
+		switch {
+		case c.Err != nil:
+			report.Errors++
+			junit.Error = &junitMessage{Message: c.Err.Error()}
+		case c.Result != nil && len(c.Result.Changes) > threshold:
+			report.Failures++
+			junit.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d changes exceed the threshold of %d", len(c.Result.Changes), threshold),
+				Text:    describeChanges(c.Result.Changes),
+			}
+		}
+		report.Cases = append(report.Cases, junit)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+//describeChanges renders the changes one per line.
+func describeChanges(changes []Change) string {
+	var buf strings.Builder
+	for _, change := range changes {
+		buf.WriteString(describeChange(change))
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+//describeChange renders the change with its values.
+func describeChange(change Change) string {
+	switch change.Kind {
+	case Added:
+		return fmt.Sprintf("%s %s: %v", change.Kind, change.Path, change.New)
+	case Removed:
+		return fmt.Sprintf("%s %s: %v", change.Kind, change.Path, change.Old)
+	case Moved:
+		if change.New == nil {
+			return fmt.Sprintf("%s from %s: %v", change.Kind, change.Path, change.Old)
+		}
+		return fmt.Sprintf("%s to %s: %v", change.Kind, change.Path, change.New)
+	}
+	return fmt.Sprintf("%s %s: %v -> %v", change.Kind, change.Path, change.Old, change.New)
+}
@@ -0,0 +1,41 @@
+package comparator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+//LatencyBudgetError is returned when fetching of either response takes longer than the latency budget.
+type LatencyBudgetError struct {
+	Budget time.Duration
+	//AExceeded and BExceeded are the amounts by which the sides exceeded the budget, zero for a side within it.
+	AExceeded time.Duration
+	BExceeded time.Duration
+}
+
+func (e *LatencyBudgetError) Error() string {
+	var sides []string
+	if e.AExceeded > 0 {
+		sides = append(sides, fmt.Sprintf("a by %v", e.AExceeded))
+	}
+	if e.BExceeded > 0 {
+		sides = append(sides, fmt.Sprintf("b by %v", e.BExceeded))
+	}
+	return fmt.Sprintf("latency budget %v exceeded: %s", e.Budget, strings.Join(sides, ", "))
+}
+
+//checkLatencyBudget returns *LatencyBudgetError if any of the latencies exceeds the configured budget.
+func (c *Comparator) checkLatencyBudget(aLatency, bLatency time.Duration) error {
+	if c.latencyBudget <= 0 || aLatency <= c.latencyBudget && bLatency <= c.latencyBudget {
+		return nil
+	}
+	err := &LatencyBudgetError{Budget: c.latencyBudget}
+	if aLatency > c.latencyBudget {
+		err.AExceeded = aLatency - c.latencyBudget
+	}
+	if bLatency > c.latencyBudget {
+		err.BExceeded = bLatency - c.latencyBudget
+	}
+	return err
+}
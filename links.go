@@ -0,0 +1,213 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+//defaultTrackingParams are the query parameters stripped from the compared links unless configured otherwise, the
+//trailing asterisk matches any suffix.
+var defaultTrackingParams = []string{"utm_*", "gclid", "fbclid", "msclkid", "dclid", "yclid", "mc_cid", "mc_eid",
+	"_ga", "_hsenc", "_hsmi"}
+
+//linkSchemes are the schemes of the compared links, others like mailto or javascript are skipped.
+var linkSchemes = map[string]bool{"http": true, "https": true}
+
+//pageLinks are the links of a page by their keys. Links to the host of the page are keyed by their paths including
+//their queries, so that the pages may be served by different hosts, others by their urls.
+type pageLinks map[string]string
+
+//compareLinks compares the href and src urls of the selected elements of html pages, the whole documents by
+//default. The links are resolved against the urls of the pages, or their base elements, and are stripped of their
+//fragments and tracking parameters. Links present on a side only are located at "links/<path>" for the links to
+//the host of the page and at "links/<url>" for the others. With link checks the links are requested by HEAD and
+//the links broken on a side only, failing or responding with a status of 400 or above, are located at
+//"broken-links/<path>" with the statuses as their values.
+func (c *Comparator) compareLinks(ctx context.Context, aURL, bURL string, aBody, bBody []byte,
+	compareElements []string) (*Result, error) {
+	aDoc, err := goquery.NewDocumentFromReader(bytes.NewReader(aBody))
+	if err != nil {
+		return nil, &ParseError{SideA, ModeLinks, err}
+	}
+	bDoc, err := goquery.NewDocumentFromReader(bytes.NewReader(bBody))
+	if err != nil {
+		return nil, &ParseError{SideB, ModeLinks, err}
+	}
+	for _, doc := range []*goquery.Document{aDoc, bDoc} {
+		if err := c.maskDocument(doc); err != nil {
+			return nil, err
+		}
+	}
+	if compareElements == nil {
+		compareElements = []string{"html"}
+	}
+	aLinks, bLinks := make(pageLinks), make(pageLinks)
+	aBase, bBase := documentBase(aDoc, aURL), documentBase(bDoc, bURL)
+	for _, element := range compareElements {
+		aElement, bElement, _, _, err := c.selectElements(aDoc, bDoc, element)
+		if err != nil {
+			return nil, err
+		}
+		c.addLinks(aLinks, aElement, aBase, aURL)
+		c.addLinks(bLinks, bElement, bBase, bURL)
+	}
+	result := &Result{}
+	compareLinkSets(result, "links/", aLinks, bLinks)
+	if c.linkChecks {
+		aBroken, bBroken := c.brokenLinks(ctx, aLinks, &c.aRequest), c.brokenLinks(ctx, bLinks, &c.bRequest)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		compareLinkSets(result, "broken-links/", aBroken, bBroken)
+	}
+	return result, nil
+}
+
+//compareLinkSets reports the keys present on a side only, sorted.
+func compareLinkSets(result *Result, prefix string, aLinks, bLinks map[string]string) {
+	keys := make([]string, 0, len(aLinks)+len(bLinks))
+	for key := range aLinks {
+		if _, ok := bLinks[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range bLinks {
+		if _, ok := aLinks[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		path := prefix + strings.TrimPrefix(key, "/")
+		if aValue, ok := aLinks[key]; ok {
+			result.add(Change{Path: path, Kind: Removed, Old: aValue}, Diff{aValue, Delete})
+		} else {
+			result.add(Change{Path: path, Kind: Added, New: bLinks[key]}, Diff{bLinks[key], Insert})
+		}
+	}
+}
+
+//documentBase returns the url the links of the document are resolved against, the href of its base element if it
+//has one.
+func documentBase(doc *goquery.Document, pageURL string) *url.URL {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		base = &url.URL{}
+	}
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if resolved, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			return resolved
+		}
+	}
+	return base
+}
+
+//addLinks adds the links of the selected elements and their descendants.
+func (c *Comparator) addLinks(links pageLinks, selection *goquery.Selection, base *url.URL, pageURL string) {
+	page, _ := url.Parse(pageURL)
+	elements := selection.Filter("[href], [src]").AddSelection(selection.Find("[href], [src]"))
+	elements.Each(func(_ int, element *goquery.Selection) {
+		for _, attribute := range []string{"href", "src"} {
+			if reference, ok := element.Attr(attribute); ok {
+				if key, link, ok := c.normalizeLink(reference, base, page); ok {
+					links[key] = link
+				}
+			}
+		}
+	})
+}
+
+//normalizeLink resolves the link and strips its fragment and tracking parameters. Links that are empty, point
+//within the page or use other schemes than http and https are skipped.
+func (c *Comparator) normalizeLink(reference string, base, page *url.URL) (string, string, bool) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" || strings.HasPrefix(reference, "#") {
+		return "", "", false
+	}
+	link, err := base.Parse(reference)
+	if err != nil || !linkSchemes[strings.ToLower(link.Scheme)] {
+		return "", "", false
+	}
+	link.Fragment, link.RawFragment = "", ""
+	link.Host = strings.ToLower(link.Host)
+	if link.RawQuery != "" {
+		query := link.Query()
+		for name := range query {
+			if c.trackingParam(name) {
+				query.Del(name)
+			}
+		}
+		link.RawQuery = query.Encode()
+	}
+	if link.Path == "" {
+		link.Path = "/"
+	}
+	if page != nil && strings.EqualFold(link.Host, page.Host) {
+		return link.RequestURI(), link.String(), true
+	}
+	return link.String(), link.String(), true
+}
+
+//trackingParam reports whether the query parameter is stripped from the links.
+func (c *Comparator) trackingParam(name string) bool {
+	params := c.trackingParams
+	if params == nil {
+		params = defaultTrackingParams
+	}
+	for _, param := range params {
+		if strings.HasSuffix(param, "*") && strings.HasPrefix(name, strings.TrimSuffix(param, "*")) ||
+			name == param {
+			return true
+		}
+	}
+	return false
+}
+
+//brokenLinks requests the links by HEAD concurrently by the configured number of workers and returns the statuses
+//of the broken ones by their keys. Links to the host of the page are requested like the page of the side, others
+//without its headers, cookies and authentication. Links not allowing HEAD are requested by GET.
+func (c *Comparator) brokenLinks(ctx context.Context, links pageLinks, request *Request) map[string]string {
+	keys := make([]string, 0, len(links))
+	for key := range links {
+		keys = append(keys, key)
+	}
+	broken := make(map[string]string)
+	var mu sync.Mutex
+	c.forEach(ctx, len(keys), func(i int) {
+		check := Request{}
+		if strings.HasPrefix(keys[i], "/") {
+			check = *request
+			check.Body = nil
+		}
+		status, ok := c.checkLink(ctx, links[keys[i]], check)
+		if !ok {
+			mu.Lock()
+			broken[keys[i]] = status
+			mu.Unlock()
+		}
+	}, func(int) {})
+	return broken
+}
+
+//checkLink requests the link and returns its status or the error, reporting whether it is not broken.
+func (c *Comparator) checkLink(ctx context.Context, link string, request Request) (string, bool) {
+	request.Method = http.MethodHead
+	resp, err := c.get(ctx, link, &request)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		closeBody(resp)
+		request.Method = http.MethodGet
+		resp, err = c.get(ctx, link, &request)
+	}
+	if err != nil {
+		return err.Error(), false
+	}
+	closeBody(resp)
+	return resp.Status, resp.StatusCode < 400
+}
@@ -0,0 +1,26 @@
+package comparator
+
+import (
+	"context"
+	"log/slog"
+)
+
+//debug logs the tracing message at the debug level if a logger is set.
+func (c *Comparator) debug(ctx context.Context, msg string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.DebugContext(ctx, msg, args...)
+	}
+}
+
+//debugging reports whether tracing messages are logged, so that expensive attributes are computed only then.
+func (c *Comparator) debugging(ctx context.Context) bool {
+	return c.logger != nil && c.logger.Enabled(ctx, slog.LevelDebug)
+}
+
+//traced logs the number of changes found by the comparison stage and returns its result.
+func (c *Comparator) traced(ctx context.Context, stage string, result *Result) *Result {
+	if result != nil {
+		c.debug(ctx, "compared "+stage, "changes", len(result.Changes))
+	}
+	return result
+}
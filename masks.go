@@ -0,0 +1,157 @@
+package comparator
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+//DefaultMask replaces the matches of masks without their own replacement.
+const DefaultMask = "<masked>"
+
+//Mask replaces the matches of the pattern in the compared values, like csrf tokens, nonces or build hashes in the
+//urls of assets, so that they are not reported as differences.
+type Mask struct {
+	Pattern *regexp.Regexp
+	//Replacement replaces the matches, $ signs in it are expanded as by regexp.Regexp.ReplaceAllString.
+	//DefaultMask is used if it is empty.
+	Replacement string
+	//Scope limits the mask to the json values matching the JSONPath or JSON Pointer pattern, like $..csrfToken, or
+	//to the texts and attributes of the html elements selected by the css selector or the xpath expression, like
+	//form. The mask applies to the whole bodies of every mode if the scope is empty.
+	Scope string
+}
+
+//scopedMask is a mask limited to json values or html elements.
+type scopedMask struct {
+	Mask
+	//path is set for json scopes, the scope is a selector otherwise.
+	path jsonPath
+}
+
+//replacement returns the replacement of the matches.
+func (m *Mask) replacement() string {
+	if m.Replacement == "" {
+		return DefaultMask
+	}
+	return m.Replacement
+}
+
+//isJSONScope reports whether the scope is a json path rather than a selector.
+func isJSONScope(scope string) bool {
+	return strings.HasPrefix(scope, "$") || strings.HasPrefix(scope, "/")
+}
+
+//maskTree masks the string values of the tree matching the json scoped masks in place, the masked root is returned
+//as it may be a string itself.
+func (c *Comparator) maskTree(value interface{}) interface{} {
+	for _, mask := range c.masks {
+		if mask.path != nil {
+			value = maskSegments(value, mask.path, &mask.Mask)
+		}
+	}
+	return value
+}
+
+func maskSegments(value interface{}, segments []pathSegment, mask *Mask) interface{} {
+	if len(segments) == 0 {
+		return maskStrings(value, mask)
+	}
+	segment := segments[0]
+	if segment.recursive {
+		direct := segment
+		direct.recursive = false
+		value = maskSegments(value, append([]pathSegment{direct}, segments[1:]...), mask)
+		return mapChildren(value, func(child interface{}) interface{} {
+			return maskSegments(child, segments, mask)
+		})
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if segment.matches(name) {
+				v[name] = maskSegments(child, segments[1:], mask)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if segment.matches(strconv.Itoa(i)) {
+				v[i] = maskSegments(child, segments[1:], mask)
+			}
+		}
+	}
+	return value
+}
+
+//maskStrings masks the value if it is a string, or every string within it.
+func maskStrings(value interface{}, mask *Mask) interface{} {
+	if text, ok := value.(string); ok {
+		return mask.Pattern.ReplaceAllString(text, mask.replacement())
+	}
+	return mapChildren(value, func(child interface{}) interface{} {
+		return maskStrings(child, mask)
+	})
+}
+
+//mapChildren replaces the members or elements of the value by the results of f in place.
+func mapChildren(value interface{}, f func(interface{}) interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			v[name] = f(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = f(child)
+		}
+	}
+	return value
+}
+
+//maskDocument masks the texts and attribute values of the elements selected by the selector scoped masks, and of
+//their descendants, in place. Selectors with the attribute suffix mask only the attribute of the selected elements.
+func (c *Comparator) maskDocument(doc *goquery.Document) error {
+	for _, mask := range c.masks {
+		if mask.path != nil {
+			continue
+		}
+		selection, attribute, err := c.selectElement(doc, mask.Scope)
+		if err != nil {
+			return err
+		}
+		for _, node := range selection.Nodes {
+			if attribute != "" {
+				maskAttribute(node, attribute, &mask.Mask)
+			} else {
+				maskNode(node, &mask.Mask)
+			}
+		}
+	}
+	return nil
+}
+
+func maskNode(node *html.Node, mask *Mask) {
+	switch node.Type {
+	case html.TextNode:
+		node.Data = mask.Pattern.ReplaceAllString(node.Data, mask.replacement())
+		return
+	case html.ElementNode:
+		for i := range node.Attr {
+			node.Attr[i].Val = mask.Pattern.ReplaceAllString(node.Attr[i].Val, mask.replacement())
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		maskNode(child, mask)
+	}
+}
+
+func maskAttribute(node *html.Node, attribute string, mask *Mask) {
+	for i := range node.Attr {
+		if node.Attr[i].Key == attribute {
+			node.Attr[i].Val = mask.Pattern.ReplaceAllString(node.Attr[i].Val, mask.replacement())
+		}
+	}
+}
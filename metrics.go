@@ -0,0 +1,151 @@
+package comparator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+//latencyBuckets are upper bounds of the latency histogram buckets in seconds.
+var latencyBuckets = [...]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+//Metrics counts comparisons of urls made by the comparators configured with WithMetrics. It is published as an
+//expvar variable with expvar.Publish, served in the Prometheus text format as an http handler or both. Metrics is
+//safe for concurrent use.
+type Metrics struct {
+	mu          sync.Mutex
+	comparisons int64
+	different   int64
+	errors      int64
+	changes     int64
+	sides       [2]sideMetrics
+}
+
+//sideMetrics are metrics of the responses of one side.
+type sideMetrics struct {
+	fetchErrors int64
+	//buckets count the latencies up to the bounds of latencyBuckets, they are not cumulative.
+	buckets [len(latencyBuckets)]int64
+	count   int64
+	sum     float64
+}
+
+//NewMetrics creates metrics without any comparison counted.
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+//record counts the comparison, the latencies are only counted for fetched responses.
+func (m *Metrics) record(result *Result, aExchange, bExchange *exchange, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.comparisons++
+	if err != nil {
+		m.errors++
+	}
+	if result != nil && !result.Equal() {
+		m.different++
+		m.changes += int64(len(result.Changes))
+	}
+	for i, e := range []*exchange{aExchange, bExchange} {
+		side := &m.sides[i]
+		if e.err != nil {
+			side.fetchErrors++
+			continue
+		}
+		if e.status == 0 {
+			continue
+		}
+		seconds := e.latency.Seconds()
+		side.count++
+		side.sum += seconds
+		for j, bound := range latencyBuckets {
+			if seconds <= bound {
+				side.buckets[j]++
+				break
+			}
+		}
+	}
+}
+
+//String renders the metrics as a json object, so that Metrics is an expvar.Var.
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sides := make(map[string]interface{}, 2)
+	for i, name := range []string{"a", "b"} {
+		side := &m.sides[i]
+		buckets := make(map[string]int64, len(latencyBuckets))
+		var cumulative int64
+		for j, bound := range latencyBuckets {
+			cumulative += side.buckets[j]
+			buckets[formatBound(bound)] = cumulative
+		}
+		sides[name] = map[string]interface{}{
+			"fetch_errors": side.fetchErrors,
+			"latency_seconds": map[string]interface{}{
+				"count":   side.count,
+				"sum":     side.sum,
+				"buckets": buckets,
+			},
+		}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"comparisons": m.comparisons,
+		"different":   m.different,
+		"errors":      m.errors,
+		"changes":     m.changes,
+		"sides":       sides,
+	})
+	return string(data)
+}
+
+//WritePrometheus writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	var buf bytes.Buffer
+	counter := func(name, help string, value int64) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+	}
+	counter("comparator_comparisons_total", "Comparisons of urls.", m.comparisons)
+	counter("comparator_different_total", "Comparisons that found differences.", m.different)
+	counter("comparator_errors_total", "Comparisons that returned an error.", m.errors)
+	counter("comparator_changes_total", "Changes found by the comparisons.", m.changes)
+	buf.WriteString("# HELP comparator_fetch_errors_total Responses that could not be fetched.\n")
+	buf.WriteString("# TYPE comparator_fetch_errors_total counter\n")
+	for i, name := range []string{"a", "b"} {
+		fmt.Fprintf(&buf, "comparator_fetch_errors_total{side=%q} %d\n", name, m.sides[i].fetchErrors)
+	}
+	buf.WriteString("# HELP comparator_latency_seconds Latencies of the responses including reading of bodies.\n")
+	buf.WriteString("# TYPE comparator_latency_seconds histogram\n")
+	for i, name := range []string{"a", "b"} {
+		side := &m.sides[i]
+		var cumulative int64
+		for j, bound := range latencyBuckets {
+			cumulative += side.buckets[j]
+			fmt.Fprintf(&buf, "comparator_latency_seconds_bucket{side=%q,le=%q} %d\n", name, formatBound(bound),
+				cumulative)
+		}
+		fmt.Fprintf(&buf, "comparator_latency_seconds_bucket{side=%q,le=\"+Inf\"} %d\n", name, side.count)
+		fmt.Fprintf(&buf, "comparator_latency_seconds_sum{side=%q} %s\n", name,
+			strconv.FormatFloat(side.sum, 'g', -1, 64))
+		fmt.Fprintf(&buf, "comparator_latency_seconds_count{side=%q} %d\n", name, side.count)
+	}
+	m.mu.Unlock()
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+//ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+func formatBound(bound float64) string {
+	return strconv.FormatFloat(bound, 'g', -1, 64)
+}
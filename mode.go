@@ -0,0 +1,194 @@
+package comparator
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+//Mode is a way the response bodies are compared.
+type Mode int8
+
+//Comparison modes. ModeAuto selects one of the others by the content type, except for ModeLinks and ModeSchema which
+//have to be set explicitly.
+const (
+	ModeAuto Mode = iota
+	ModeJSON
+	ModeHTML
+	ModeXML
+	ModeYAML
+	ModeText
+	ModeBinary
+	ModeImage
+	ModeNDJSON
+	ModeCSV
+	ModeProtobuf
+	ModePDF
+	ModeFeed
+	ModeLinks
+	ModeSchema
+)
+
+//modeNames are the names of the modes as used by command line flags and config files.
+var modeNames = []string{"auto", "json", "html", "xml", "yaml", "text", "binary", "image", "ndjson", "csv", "protobuf",
+	"pdf", "feed", "links", "schema"}
+
+func (m Mode) String() string {
+	if m >= 0 && int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return "unknown"
+}
+
+//MarshalText renders the mode by its name.
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+//UnmarshalText parses the mode name.
+func (m *Mode) UnmarshalText(text []byte) error {
+	for i, name := range modeNames {
+		if strings.EqualFold(name, string(text)) {
+			*m = Mode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mode %q", text)
+}
+
+//builtinModes maps well known media types and structured syntax suffixes to comparison modes.
+var builtinModes = map[string]Mode{
+	"application/json":                ModeJSON,
+	"text/json":                       ModeJSON,
+	"+json":                           ModeJSON,
+	"text/html":                       ModeHTML,
+	"application/xhtml+xml":           ModeHTML,
+	"application/xml":                 ModeXML,
+	"text/xml":                        ModeXML,
+	"+xml":                            ModeXML,
+	"application/yaml":                ModeYAML,
+	"application/x-yaml":              ModeYAML,
+	"text/yaml":                       ModeYAML,
+	"text/x-yaml":                     ModeYAML,
+	"+yaml":                           ModeYAML,
+	"application/x-ndjson":            ModeNDJSON,
+	"application/ndjson":              ModeNDJSON,
+	"application/jsonl":               ModeNDJSON,
+	"application/jsonlines":           ModeNDJSON,
+	"text/csv":                        ModeCSV,
+	"application/csv":                 ModeCSV,
+	"application/x-protobuf":          ModeProtobuf,
+	"application/protobuf":            ModeProtobuf,
+	"application/vnd.google.protobuf": ModeProtobuf,
+	"application/pdf":                 ModePDF,
+	"application/rss+xml":             ModeFeed,
+	"application/atom+xml":            ModeFeed,
+	"image/png":                       ModeImage,
+	"image/jpeg":                      ModeImage,
+	"image/gif":                       ModeImage,
+}
+
+//genericTypes don't tell much about the body, so it is sniffed when a response has one of them. Servers often
+//send them by default.
+var genericTypes = map[string]bool{
+	"text/plain":               true,
+	"application/octet-stream": true,
+}
+
+//binaryTypes are prefixes of media types compared as binary.
+var binaryTypes = []string{
+	"application/octet-stream",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/wasm",
+	"image/",
+	"audio/",
+	"video/",
+	"font/",
+}
+
+//detectMode selects comparison mode unless the mode is set explicitly. The content types of the bodies are consulted
+//first, then the bodies are sniffed if the content types are missing or too generic. Bodies starting like json
+//objects or arrays are compared as json, unrecognized ones as text. Bodies that are both rss or atom feeds are
+//compared as feeds, even without an xml declaration.
+func (c *Comparator) detectMode(aBody, bBody []byte, aContentType, bContentType string, mode Mode) Mode {
+	if mode != ModeAuto {
+		return mode
+	}
+	for _, contentType := range []string{aContentType, bContentType} {
+		if mode, ok := c.modeForContentType(contentType, false); ok {
+			return feedOrMode(mode, aBody, bBody)
+		}
+	}
+	for _, body := range [][]byte{aBody, bBody} {
+		if looksLikeJSON(body) {
+			return ModeJSON
+		}
+	}
+	if isFeed(aBody) && isFeed(bBody) {
+		return ModeFeed
+	}
+	for _, body := range [][]byte{aBody, bBody} {
+		if mode, ok := c.modeForContentType(http.DetectContentType(body), true); ok {
+			return feedOrMode(mode, aBody, bBody)
+		}
+	}
+	return ModeText
+}
+
+//feedOrMode upgrades the xml mode to the feed mode for feeds.
+func feedOrMode(mode Mode, aBody, bBody []byte) Mode {
+	if mode == ModeXML && isFeed(aBody) && isFeed(bBody) {
+		return ModeFeed
+	}
+	return mode
+}
+
+func looksLikeJSON(body []byte) bool {
+	body = bytes.TrimSpace(body)
+	return len(body) > 0 && (body[0] == '{' || body[0] == '[')
+}
+
+//modeForContentType consults custom content type mappings before the built-in ones. Exact media types take
+//precedence over the structured syntax suffix (like +json or +xml) matches. Other text types are compared as
+//text and known binary types as binary, generic types are only recognized if allowed.
+func (c *Comparator) modeForContentType(contentType string, generic bool) (Mode, bool) {
+	mediaType := mediaTypeOf(contentType)
+	if mediaType == "" {
+		return 0, false
+	}
+	for _, modes := range []map[string]Mode{c.contentTypeModes, builtinModes} {
+		if mode, ok := modes[mediaType]; ok {
+			return mode, true
+		}
+		if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+			if mode, ok := modes[mediaType[i:]]; ok {
+				return mode, true
+			}
+		}
+	}
+	if genericTypes[mediaType] && !generic {
+		return 0, false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return ModeText, true
+	}
+	for _, prefix := range binaryTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return ModeBinary, true
+		}
+	}
+	return 0, false
+}
+
+//mediaTypeOf returns the lower case media type of the content type without parameters.
+func mediaTypeOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
@@ -0,0 +1,95 @@
+package comparator
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+//minMoveLength is the minimal length of a trimmed block to be considered as moved. Shorter blocks match by
+//accident too often.
+const minMoveLength = 10
+
+//markMoves replaces every deleted block which reappears as an inserted block with a single Move diff placed at
+//the position of the deletion. The matching insertion is dropped.
+func markMoves(diffs []Diff) []Diff {
+	inserted := make(map[string][]int)
+	for i, diff := range diffs {
+		if diff.Type == Insert {
+			if key := strings.TrimSpace(diff.Text); len(key) >= minMoveLength {
+				inserted[key] = append(inserted[key], i)
+			}
+		}
+	}
+	if len(inserted) == 0 {
+		return diffs
+	}
+	moves := make(map[int]bool)
+	dropped := make(map[int]bool)
+	for i, diff := range diffs {
+		if diff.Type != Delete {
+			continue
+		}
+		key := strings.TrimSpace(diff.Text)
+		if positions := inserted[key]; len(positions) > 0 {
+			moves[i] = true
+			dropped[positions[0]] = true
+			inserted[key] = positions[1:]
+		}
+	}
+	if len(moves) == 0 {
+		return diffs
+	}
+	result := make([]Diff, 0, len(diffs)-len(dropped))
+	for i, diff := range diffs {
+		if dropped[i] {
+			continue
+		}
+		if moves[i] {
+			diff.Type = Move
+		}
+		result = append(result, diff)
+	}
+	return result
+}
+
+//markMovedChanges marks every removed change whose value reappears as an added change, and that added change, as
+//a pair of Moved changes as markMoves does for the flat diffs. Values match by their trimmed text, values other
+//than strings by their json.
+func markMovedChanges(changes []Change) {
+	added := make(map[string][]int)
+	for i, change := range changes {
+		if change.Kind == Added {
+			if key, ok := moveKey(change.New); ok {
+				added[key] = append(added[key], i)
+			}
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	for i, change := range changes {
+		if change.Kind != Removed {
+			continue
+		}
+		key, ok := moveKey(change.Old)
+		if positions := added[key]; ok && len(positions) > 0 {
+			changes[i].Kind = Moved
+			changes[positions[0]].Kind = Moved
+			added[key] = positions[1:]
+		}
+	}
+}
+
+//moveKey returns the text the moved value is matched by, values shorter than minMoveLength are never moved.
+func moveKey(value interface{}) (string, bool) {
+	key, ok := value.(string)
+	if !ok {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		key = string(encoded)
+	}
+	key = strings.TrimSpace(key)
+	return key, len(key) >= minMoveLength
+}
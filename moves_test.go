@@ -0,0 +1,37 @@
+package comparator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoveDetectionMarksChanges(t *testing.T) {
+	a := []byte(`{"items":[{"id":1,"name":"first item"},{"id":2,"name":"second item"},{"id":3,"name":"third item"}]}`)
+	b := []byte(`{"items":[{"id":2,"name":"second item"},{"id":3,"name":"third item"},{"id":1,"name":"first item"}]}`)
+	moved := map[string]interface{}{"id": 1.0, "name": "first item"}
+
+	result, err := New(WithMode(ModeJSON), WithMoveDetection()).CompareBytesResult(a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{{"/items/0", Moved, moved, nil}, {"/items/2", Moved, nil, moved}}
+	if !reflect.DeepEqual(result.Changes, want) {
+		t.Errorf("got changes %v, want %v", result.Changes, want)
+	}
+
+	result, err = New(WithMode(ModeJSON)).CompareBytesResult(a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 2 || result.Changes[0].Kind != Removed || result.Changes[1].Kind != Added {
+		t.Errorf("changes %v are marked as moved without the move detection", result.Changes)
+	}
+}
+
+func TestMoveDetectionSkipsShortValues(t *testing.T) {
+	changes := []Change{{"/0", Removed, "a", nil}, {"/1", Added, nil, "a"}}
+	markMovedChanges(changes)
+	if changes[0].Kind != Removed || changes[1].Kind != Added {
+		t.Errorf("short values are marked as moved: %v", changes)
+	}
+}
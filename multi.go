@@ -0,0 +1,83 @@
+package comparator
+
+import "context"
+
+//CandidateResult is the comparison of a candidate with the baseline.
+type CandidateResult struct {
+	URL    string
+	Result *Result
+	Err    error
+}
+
+//MultiResult is the comparison of several candidates with the same baseline response.
+type MultiResult struct {
+	Baseline   string
+	Candidates []CandidateResult
+}
+
+//Divergent returns the candidates that differ from the baseline or could not be compared.
+func (r *MultiResult) Divergent() []CandidateResult {
+	var divergent []CandidateResult
+	for _, candidate := range r.Candidates {
+		if candidate.Err != nil || !candidate.Result.Equal() {
+			divergent = append(divergent, candidate)
+		}
+	}
+	return divergent
+}
+
+//Divergences maps paths of the changes to the urls of the candidates that diverge from the baseline there, so
+//differences of all candidates are told from the ones of some candidates.
+func (r *MultiResult) Divergences() map[string][]string {
+	divergences := make(map[string][]string)
+	for _, candidate := range r.Candidates {
+		if candidate.Result == nil {
+			continue
+		}
+		seen := make(map[string]bool, len(candidate.Result.Changes))
+		for _, change := range candidate.Result.Changes {
+			if !seen[change.Path] {
+				seen[change.Path] = true
+				divergences[change.Path] = append(divergences[change.Path], candidate.URL)
+			}
+		}
+	}
+	return divergences
+}
+
+//CompareN compares the candidates with the baseline using the default options.
+func CompareN(ctx context.Context, baseline string, candidates []string, compareElements []string) (*MultiResult,
+	error) {
+	return defaultComparator.CompareN(ctx, baseline, candidates, compareElements)
+}
+
+//Compare3 compares both candidates with the baseline using the default options.
+func Compare3(ctx context.Context, baseline, aCandidate, bCandidate string, compareElements []string) (*MultiResult,
+	error) {
+	return defaultComparator.Compare3(ctx, baseline, aCandidate, bCandidate, compareElements)
+}
+
+//CompareN fetches the baseline once as the a side and compares the candidates fetched concurrently as the b side
+//with it, so all candidates are compared with the same response. An error is returned if the baseline could not be
+//fetched, comparison errors of the candidates are returned along with their results.
+func (c *Comparator) CompareN(ctx context.Context, baseline string, candidates []string, compareElements []string) (
+	*MultiResult, error) {
+	snapshot, err := c.TakeSnapshot(ctx, baseline)
+	if err != nil {
+		return nil, err
+	}
+	result := &MultiResult{Baseline: baseline, Candidates: make([]CandidateResult, len(candidates))}
+	c.forEach(ctx, len(candidates), func(i int) {
+		compared, err := c.compareSnapshot(ctx, snapshot, candidates[i], &c.bRequest, compareElements)
+		result.Candidates[i] = CandidateResult{candidates[i], compared, err}
+	}, func(i int) {
+		result.Candidates[i] = CandidateResult{URL: candidates[i], Err: ctx.Err()}
+	})
+	return result, nil
+}
+
+//Compare3 compares both candidates with the baseline as CompareN does.
+func (c *Comparator) Compare3(ctx context.Context, baseline, aCandidate, bCandidate string, compareElements []string) (
+	*MultiResult, error) {
+	return c.CompareN(ctx, baseline, []string{aCandidate, bCandidate}, compareElements)
+}
@@ -0,0 +1,105 @@
+package comparator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//FormFile is a file part of a multipart form.
+type FormFile struct {
+	//Field is the name of the form field.
+	Field string
+	//Name is the file name sent with the part.
+	Name string
+	//ContentType defaults to application/octet-stream.
+	ContentType string
+	Content     io.Reader
+}
+
+//SetMultipartBody sets the body of the request to the multipart/form-data form of the fields and the files, and its
+//Content-Type header. The method defaults to POST. The files are read once when the body is built, so the request
+//sends the same body to both sides and to every comparison. The boundary is derived from the parts, so requests
+//built from the same parts have identical bodies too.
+func (r *Request) SetMultipartBody(fields url.Values, files ...FormFile) error {
+	contents := make([][]byte, len(files))
+	hash := sha256.New()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range fields[name] {
+			fmt.Fprintf(hash, "%q=%q\n", name, value)
+		}
+	}
+	for i, file := range files {
+		content, err := ioutil.ReadAll(file.Content)
+		if err != nil {
+			return fmt.Errorf("multipart file %s: %v", file.Name, err)
+		}
+		contents[i] = content
+		fmt.Fprintf(hash, "%q=%q;%q\n", file.Field, file.Name, file.ContentType)
+		hash.Write(content)
+	}
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.SetBoundary("comparator" + hex.EncodeToString(hash.Sum(nil))[:40]); err != nil {
+		return err
+	}
+	for _, name := range names {
+		for _, value := range fields[name] {
+			if err := writer.WriteField(name, value); err != nil {
+				return err
+			}
+		}
+	}
+	for i, file := range files {
+		contentType := file.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+			escapeQuotes(file.Field), escapeQuotes(file.Name)))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := part.Write(contents[i]); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if r.Method == "" {
+		r.Method = http.MethodPost
+	}
+	header := make(http.Header, len(r.Header)+1)
+	for name, values := range r.Header {
+		header[name] = values
+	}
+	header.Set("Content-Type", writer.FormDataContentType())
+	r.Header = header
+	r.Body = body.Bytes()
+	return nil
+}
+
+//quoteEscaper escapes the quoted strings of multipart headers as mime/multipart does.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
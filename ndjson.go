@@ -0,0 +1,50 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+//compareNDJSONs compares newline delimited json records. Records are matched by the record key if every record
+//of both sides has a unique one and addressed by it, like /42/name, otherwise records are diffed as arrays and
+//addressed by their position.
+func (c *Comparator) compareNDJSONs(ctx context.Context, aBody, bBody []byte) (*Result, error) {
+	aRecords := decodeNDJSON(aBody)
+	bRecords := decodeNDJSON(bBody)
+	if aKeyed, bKeyed, ok := c.keyBoth(aRecords, bRecords); ok {
+		return c.compareTrees(ctx, []interface{}{aKeyed}, []interface{}{bKeyed}, "", "")
+	}
+	return c.compareTrees(ctx, []interface{}{aRecords}, []interface{}{bRecords}, "", "")
+}
+
+//decodeNDJSON decodes every non-empty line of the body. Lines that are not valid json are kept as strings, so
+//they are still compared.
+func decodeNDJSON(body []byte) []interface{} {
+	records := []interface{}{}
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var record interface{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			record = string(line)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+//keyRecords maps the records by their keys. It reports false if a record lacks the key or keys repeat.
+func keyRecords(records []interface{}, key string) (map[string]interface{}, bool) {
+	keyed := make(map[string]interface{}, len(records))
+	for _, record := range records {
+		id, ok := elementKey(record, key)
+		if _, duplicate := keyed[id]; !ok || duplicate {
+			return nil, false
+		}
+		keyed[id] = record
+	}
+	return keyed, true
+}
@@ -0,0 +1,52 @@
+package comparator
+
+import (
+	"regexp"
+	"strings"
+)
+
+//Normalization is a set of text normalizations applied before text is compared. Normalizations are combined with
+//the bitwise or.
+type Normalization uint8
+
+//Text normalizations.
+const (
+	//CollapseWhitespace replaces every run of whitespace with a single space.
+	CollapseWhitespace Normalization = 1 << iota
+	//TrimLines removes leading and trailing whitespace of every line.
+	TrimLines
+	//IgnoreCase compares text case-insensitively.
+	IgnoreCase
+	//StripHTMLComments removes html comments.
+	StripHTMLComments
+)
+
+var (
+	whitespaceRun = regexp.MustCompile(`\s+`)
+	htmlComment   = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+//normalize applies the enabled normalizations to text of the element, the attribute or the body. Comments are
+//stripped first, then lines are trimmed, whitespace is collapsed and the case is folded.
+func (c *Comparator) normalize(text string) string {
+	if c.normalizations == 0 {
+		return text
+	}
+	if c.normalizations&StripHTMLComments != 0 {
+		text = htmlComment.ReplaceAllString(text, "")
+	}
+	if c.normalizations&TrimLines != 0 {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace(line)
+		}
+		text = strings.Join(lines, "\n")
+	}
+	if c.normalizations&CollapseWhitespace != 0 {
+		text = strings.TrimSpace(whitespaceRun.ReplaceAllString(text, " "))
+	}
+	if c.normalizations&IgnoreCase != 0 {
+		text = strings.ToLower(text)
+	}
+	return text
+}
@@ -0,0 +1,142 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+)
+
+//Normalizer rewrites a body before it is compared, for example to mask values specific to the environment. The
+//content type is the one of the response and is empty for payloads compared without it. Normalizers must be safe
+//for concurrent use.
+type Normalizer interface {
+	Normalize(body []byte, contentType string) ([]byte, error)
+}
+
+//NormalizerFunc adapts a function to the Normalizer interface.
+type NormalizerFunc func(body []byte, contentType string) ([]byte, error)
+
+//Normalize calls f(body, contentType).
+func (f NormalizerFunc) Normalize(body []byte, contentType string) ([]byte, error) {
+	return f(body, contentType)
+}
+
+//Masks written by the built-in normalizers.
+const (
+	TimestampMask = "<timestamp>"
+	UUIDMask      = "<uuid>"
+)
+
+var (
+	//timestampPattern matches ISO 8601 date times like 2006-01-02T15:04:05.999Z and http dates like
+	//Mon, 02 Jan 2006 15:04:05 GMT.
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?|` +
+		`(Mon|Tue|Wed|Thu|Fri|Sat|Sun), \d{2} (Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) \d{4} ` +
+		`\d{2}:\d{2}:\d{2} GMT`)
+	uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+)
+
+//MaskTimestamps replaces ISO 8601 date times and http dates with TimestampMask.
+func MaskTimestamps() Normalizer {
+	return ReplaceRegexp(timestampPattern, TimestampMask)
+}
+
+//MaskUUIDs replaces UUIDs with UUIDMask.
+func MaskUUIDs() Normalizer {
+	return ReplaceRegexp(uuidPattern, UUIDMask)
+}
+
+//ReplaceRegexp replaces matches of the pattern with the replacement, $ signs in it are expanded as by
+//regexp.Regexp.ReplaceAll.
+func ReplaceRegexp(pattern *regexp.Regexp, replacement string) Normalizer {
+	return NormalizerFunc(func(body []byte, _ string) ([]byte, error) {
+		return pattern.ReplaceAll(body, []byte(replacement)), nil
+	})
+}
+
+//SortJSONKeys rewrites json bodies with the object keys sorted, so that texts of the bodies can be compared
+//regardless of the key order. Bodies of json streams are rewritten value by value, other bodies are left as they
+//are. Numbers are kept as written.
+func SortJSONKeys() Normalizer {
+	return NormalizerFunc(func(body []byte, contentType string) ([]byte, error) {
+		isJSON := strings.Contains(strings.ToLower(contentType), "json")
+		if !isJSON && (contentType != "" || !looksLikeJSON(body)) {
+			return body, nil
+		}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		for {
+			var value interface{}
+			err := decoder.Decode(&value)
+			if err == io.EOF {
+				return buf.Bytes(), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if err := encoder.Encode(value); err != nil {
+				return nil, err
+			}
+		}
+	})
+}
+
+//CanonicalizeJSON rewrites json bodies in the canonical form of RFC 8785 as CanonicalJSON does, so that the key
+//order, escaped or raw unicode and the number formatting, like 1.0 for 1 or 1e3 for 1000, don't differ in any
+//output. Bodies of json streams are rewritten value by value, one per line, other bodies are left as they are.
+func CanonicalizeJSON() Normalizer {
+	return NormalizerFunc(func(body []byte, contentType string) ([]byte, error) {
+		isJSON := strings.Contains(strings.ToLower(contentType), "json")
+		if !isJSON && (contentType != "" || !looksLikeJSON(body)) {
+			return body, nil
+		}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var buf bytes.Buffer
+		for values := 0; ; values++ {
+			var value interface{}
+			err := decoder.Decode(&value)
+			if err == io.EOF {
+				return buf.Bytes(), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if values > 0 {
+				buf.WriteByte('\n')
+			}
+			if err := writeCanonical(&buf, value); err != nil {
+				return nil, err
+			}
+		}
+	})
+}
+
+//normalizeBody applies the normalizers to the body in order, the json canonicalization last. Pdf bodies are left
+//intact, the text of their pages is normalized instead.
+func (c *Comparator) normalizeBody(ctx context.Context, body []byte, contentType string) ([]byte, error) {
+	if bytes.HasPrefix(body, pdfMagic) {
+		return body, nil
+	}
+	for i, normalizer := range c.bodyNormalizers {
+		normalized, err := normalizer.Normalize(body, contentType)
+		if err != nil {
+			return nil, err
+		}
+		if c.debugging(ctx) && !bytes.Equal(normalized, body) {
+			c.debug(ctx, "body normalized", "normalizer", i, "content_type", contentType, "size", len(body),
+				"normalized_size", len(normalized))
+		}
+		body = normalized
+	}
+	if c.canonicalJSON {
+		return CanonicalizeJSON().Normalize(body, contentType)
+	}
+	return body, nil
+}
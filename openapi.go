@@ -0,0 +1,239 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//APIOperation is a GET operation of an OpenAPI spec resolved to a pair of urls.
+type APIOperation struct {
+	OperationID string
+	//Path is the templated path of the spec like /users/{id}.
+	Path string
+	Pair Pair
+}
+
+//APIOperationResult is the comparison of the urls of an operation.
+type APIOperationResult struct {
+	APIOperation
+	Result *Result
+	Err    error
+}
+
+//APIReport aggregates comparisons of the operations of a spec. Operations are counted as equal, different or
+//failed, failed ones include the operations with a side that could not be fetched, while the skipped ones are GET
+//operations with required parameters without values.
+type APIReport struct {
+	Operations []APIOperationResult
+	Skipped    []string
+	Equal      int
+	Different  int
+	Failed     int
+}
+
+//OpenAPIOperations resolves the GET operations of the OpenAPI 3 or Swagger 2 spec, in json or yaml, to pairs of urls
+//against both base urls. The base path of the spec, the basePath of Swagger 2 or the path of the first server url of
+//OpenAPI 3, is put in front of the operation paths. Parameters get the supplied values by their names first, then the
+//examples and the defaults of the spec. Optional query parameters without values are left out, operations with required
+//parameters without values are returned as skipped like "GET /users/{id}". Operations are ordered by their paths.
+func OpenAPIOperations(spec []byte, aBase, bBase string, params map[string]string) ([]APIOperation, []string,
+	error) {
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(spec, &document); err != nil {
+		return nil, nil, err
+	}
+	basePath := specBasePath(document)
+	paths, _ := document["paths"].(map[string]interface{})
+	templates := make([]string, 0, len(paths))
+	for template := range paths {
+		templates = append(templates, template)
+	}
+	sort.Strings(templates)
+	var operations []APIOperation
+	var skipped []string
+	for _, template := range templates {
+		item, _ := resolveRef(document, paths[template]).(map[string]interface{})
+		get, ok := item["get"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path, ok := operationPath(document, template, item, get, params)
+		if !ok {
+			skipped = append(skipped, "GET "+template)
+			continue
+		}
+		operationID, _ := get["operationId"].(string)
+		path = basePath + "/" + strings.TrimPrefix(path, "/")
+		operations = append(operations, APIOperation{operationID, template,
+			Pair{AURL: joinURL(aBase, path), BURL: joinURL(bBase, path)}})
+	}
+	return operations, skipped, nil
+}
+
+//specBasePath returns the base path of the spec without the trailing slash, the basePath of Swagger 2 or the path
+//of the first server url of OpenAPI 3 with its variables replaced by their defaults.
+func specBasePath(document map[string]interface{}) string {
+	if basePath, ok := document["basePath"].(string); ok {
+		return strings.TrimSuffix(basePath, "/")
+	}
+	servers, _ := document["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]interface{})
+	serverURL, _ := server["url"].(string)
+	variables, _ := server["variables"].(map[string]interface{})
+	for name, variable := range variables {
+		variable, _ := variable.(map[string]interface{})
+		serverURL = strings.Replace(serverURL, "{"+name+"}", fmt.Sprint(variable["default"]), -1)
+	}
+	parsed, err := url.Parse(serverURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(parsed.Path, "/")
+}
+
+//operationPath fills the parameters of the operation and of its path item into the path template. Path
+//parameters not declared by the spec are filled by the supplied values too.
+func operationPath(document map[string]interface{}, template string, item, operation map[string]interface{},
+	params map[string]string) (string, bool) {
+	path := template
+	query := url.Values{}
+	parameters, _ := item["parameters"].([]interface{})
+	if own, ok := operation["parameters"].([]interface{}); ok {
+		parameters = append(append([]interface{}{}, parameters...), own...)
+	}
+	for _, parameter := range parameters {
+		p, _ := resolveRef(document, parameter).(map[string]interface{})
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		required, _ := p["required"].(bool)
+		if in != "path" && in != "query" {
+			continue
+		}
+		values, ok := parameterValues(document, p, params)
+		if !ok {
+			if required || in == "path" {
+				return "", false
+			}
+			continue
+		}
+		if in == "path" {
+			path = strings.Replace(path, "{"+name+"}", url.PathEscape(strings.Join(values, ",")), -1)
+		} else {
+			query[name] = values
+		}
+	}
+	for name, value := range params {
+		path = strings.Replace(path, "{"+name+"}", url.PathEscape(value), -1)
+	}
+	if strings.Contains(path, "{") {
+		return "", false
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, true
+}
+
+//parameterValues returns the supplied value of the parameter or its example or default. Array examples give
+//several values.
+func parameterValues(document, parameter map[string]interface{}, params map[string]string) ([]string, bool) {
+	name, _ := parameter["name"].(string)
+	if value, ok := params[name]; ok {
+		return []string{value}, true
+	}
+	schema, _ := resolveRef(document, parameter["schema"]).(map[string]interface{})
+	candidates := []interface{}{parameter["example"], firstExample(document, parameter), parameter["x-example"],
+		schema["example"], parameter["default"], schema["default"]}
+	if values, ok := schema["enum"].([]interface{}); ok && len(values) > 0 {
+		candidates = append(candidates, values[0])
+	}
+	for _, candidate := range candidates {
+		switch value := candidate.(type) {
+		case nil:
+			continue
+		case []interface{}:
+			values := make([]string, len(value))
+			for i, element := range value {
+				values[i] = fmt.Sprint(element)
+			}
+			return values, true
+		default:
+			return []string{fmt.Sprint(value)}, true
+		}
+	}
+	return nil, false
+}
+
+//firstExample returns the value of the first of the named examples of the parameter in the order of their names.
+func firstExample(document, parameter map[string]interface{}) interface{} {
+	examples, _ := parameter["examples"].(map[string]interface{})
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if example, ok := resolveRef(document, examples[name]).(map[string]interface{}); ok {
+			return example["value"]
+		}
+	}
+	return nil
+}
+
+//resolveRef resolves the local $ref of the value like "#/components/parameters/id", other values are returned as
+//they are.
+func resolveRef(document map[string]interface{}, value interface{}) interface{} {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	ref, ok := object["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#/") {
+		return value
+	}
+	return resolvePointer(document, strings.TrimPrefix(ref, "#"))
+}
+
+//CompareOpenAPI compares the GET operations of the spec against both base urls using the default options.
+func CompareOpenAPI(ctx context.Context, spec []byte, aBase, bBase string, params map[string]string) (*APIReport,
+	error) {
+	return defaultComparator.CompareOpenAPI(ctx, spec, aBase, bBase, params)
+}
+
+//CompareOpenAPI compares the GET operations of the spec resolved by OpenAPIOperations against both base urls as a
+//batch and aggregates the results.
+func (c *Comparator) CompareOpenAPI(ctx context.Context, spec []byte, aBase, bBase string,
+	params map[string]string) (*APIReport, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	operations, skipped, err := OpenAPIOperations(spec, aBase, bBase, params)
+	if err != nil {
+		return nil, err
+	}
+	pairs := make([]Pair, len(operations))
+	for i, operation := range operations {
+		pairs[i] = operation.Pair
+	}
+	report := &APIReport{Operations: make([]APIOperationResult, len(operations)), Skipped: skipped}
+	for i, result := range c.CompareBatch(ctx, pairs) {
+		report.Operations[i] = APIOperationResult{operations[i], result.Result, result.Err}
+		switch {
+		case result.Err != nil || len(result.Result.FetchErrors()) > 0:
+			report.Failed++
+		case result.Result.Equal():
+			report.Equal++
+		default:
+			report.Different++
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,86 @@
+package comparator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPIOperationsBasePath(t *testing.T) {
+	specs := map[string]string{
+		"swagger 2": `
+swagger: "2.0"
+basePath: /v1/
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - {name: id, in: path, required: true, type: integer, x-example: 7}
+  /health:
+    get:
+      operationId: health
+`,
+		"openapi 3": `
+openapi: 3.0.0
+servers:
+  - url: "https://api.example.com/{version}"
+    variables:
+      version: {default: v1}
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer, example: 7}}
+  /health:
+    get:
+      operationId: health
+`,
+		"openapi 3 relative server": `
+openapi: 3.0.0
+servers:
+  - url: /v1
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - {name: id, in: path, required: true, example: 7}
+  /health:
+    get:
+      operationId: health
+`,
+	}
+	want := []Pair{
+		{AURL: "http://a.test/v1/health", BURL: "http://b.test/v1/health"},
+		{AURL: "http://a.test/v1/users/7", BURL: "http://b.test/v1/users/7"},
+	}
+	for name, spec := range specs {
+		operations, skipped, err := OpenAPIOperations([]byte(spec), "http://a.test/", "http://b.test", nil)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(skipped) > 0 {
+			t.Errorf("%s: unexpected skipped operations %v", name, skipped)
+		}
+		var pairs []Pair
+		for _, operation := range operations {
+			pairs = append(pairs, operation.Pair)
+		}
+		if !reflect.DeepEqual(pairs, want) {
+			t.Errorf("%s: got pairs %v, want %v", name, pairs, want)
+		}
+	}
+}
+
+func TestOpenAPIOperationsWithoutBasePath(t *testing.T) {
+	spec := `{"openapi": "3.0.0", "paths": {"/health": {"get": {}}}}`
+	operations, _, err := OpenAPIOperations([]byte(spec), "http://a.test", "http://b.test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(operations) != 1 || operations[0].Pair.AURL != "http://a.test/health" {
+		t.Errorf("unexpected operations %v", operations)
+	}
+}
@@ -0,0 +1,648 @@
+package comparator
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+//Option configures a Comparator.
+type Option func(*Comparator)
+
+//setErr keeps the first error of the options.
+func (c *Comparator) setErr(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+//WithMoveDetection reports blocks that were deleted in one place and inserted in another as a single Move diff
+//instead of a Delete and an Insert, and the removed and added changes of the same value as a pair of Moved changes.
+//It is disabled by default because of the extra computation.
+func WithMoveDetection() Option {
+	return func(c *Comparator) {
+		c.detectMoves = true
+	}
+}
+
+//WithContentTypeModes maps content types to comparison modes. Keys are either media types
+//(application/vnd.myapi+json) or structured syntax suffixes (+json, +xml). These mappings are consulted before
+//the built-in ones when the mode is selected by the response content type.
+func WithContentTypeModes(modes map[string]Mode) Option {
+	return func(c *Comparator) {
+		if c.contentTypeModes == nil {
+			c.contentTypeModes = make(map[string]Mode, len(modes))
+		}
+		for contentType, mode := range modes {
+			c.contentTypeModes[strings.ToLower(contentType)] = mode
+		}
+	}
+}
+
+//WithVisibleTextOnly skips text of html elements hidden by the hidden or aria-hidden="true" attributes or by the
+//display:none and visibility:hidden inline styles. Stylesheets are not evaluated.
+func WithVisibleTextOnly() Option {
+	return func(c *Comparator) {
+		c.visibleTextOnly = true
+	}
+}
+
+//WithLatencyBudget fails the comparison with *LatencyBudgetError if receiving either response takes longer than
+//the budget, regardless of the content equality. The diffs are still returned along with the error unless fail
+//fast is enabled.
+func WithLatencyBudget(budget time.Duration) Option {
+	return func(c *Comparator) {
+		c.latencyBudget = budget
+	}
+}
+
+//WithFailFast skips the content comparison when a check like the latency budget has already failed.
+func WithFailFast() Option {
+	return func(c *Comparator) {
+		c.failFast = true
+	}
+}
+
+//WithCookieComparison compares cookies set by the responses attribute by attribute. Cookies are matched by name
+//and every differing attribute is reported as a separate pair of diffs before the body diffs.
+func WithCookieComparison() Option {
+	return func(c *Comparator) {
+		c.compareCookies = true
+	}
+}
+
+//WithSecurityComparison compares the tls connections of the responses, like their protocol versions, cipher suites
+//and certificates, and their security headers, like Strict-Transport-Security and Content-Security-Policy. They
+//are reported after the cookies and before the body diffs.
+func WithSecurityComparison() Option {
+	return func(c *Comparator) {
+		c.compareSecurity = true
+	}
+}
+
+//WithProtocolComparison compares the http protocols the responses were sent by, like HTTP/1.1 and HTTP/2.0, and
+//their trailers, so that differences of the protocols forced for the sides by their requests are surfaced. The
+//protocols are reported after the security comparison, the trailers after the body diffs as they are received
+//after the bodies. Ignored headers are not compared as trailers either.
+func WithProtocolComparison() Option {
+	return func(c *Comparator) {
+		c.compareProtocol = true
+	}
+}
+
+//WithHostSubstitutions replaces every occurrence of the hostnames (keys) in both bodies with their canonical names
+//(values), so that comparing environments like staging.example.com and example.com reports only real content
+//differences. Substitutions are applied to the decompressed body before any mode specific parsing. Longer
+//hostnames are replaced first.
+func WithHostSubstitutions(substitutions map[string]string) Option {
+	return func(c *Comparator) {
+		hosts := make([]string, 0, len(substitutions))
+		for host := range substitutions {
+			hosts = append(hosts, host)
+		}
+		sort.Slice(hosts, func(i, j int) bool {
+			if len(hosts[i]) != len(hosts[j]) {
+				return len(hosts[i]) > len(hosts[j])
+			}
+			return hosts[i] < hosts[j]
+		})
+		pairs := make([]string, 0, 2*len(hosts))
+		for _, host := range hosts {
+			pairs = append(pairs, host, substitutions[host])
+		}
+		c.hostReplacer = strings.NewReplacer(pairs...)
+	}
+}
+
+//WithHTTPClient sets the client used to fetch the responses, so that timeouts, proxies or tls settings can be
+//configured. http.DefaultClient is used by default.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Comparator) {
+		c.client = client
+	}
+}
+
+//WithRequest sets method, headers, body and cookies used to request both sides.
+func WithRequest(request Request) Option {
+	return WithSideRequests(request, request)
+}
+
+//WithSideRequests sets separate requests for the sides, for example to use different credentials for them.
+func WithSideRequests(a, b Request) Option {
+	return func(c *Comparator) {
+		c.aRequest = a
+		c.bRequest = b
+	}
+}
+
+//WithSideAuth sets the authenticators of the side requests keeping the rest of the requests, a nil authenticator
+//keeps the authentication of its side.
+func WithSideAuth(a, b Authenticator) Option {
+	return func(c *Comparator) {
+		if a != nil {
+			c.aRequest.Auth = a
+		}
+		if b != nil {
+			c.bRequest.Auth = b
+		}
+	}
+}
+
+//WithStatusComparison reports different status codes of the responses before any other diffs.
+func WithStatusComparison() Option {
+	return func(c *Comparator) {
+		c.compareStatus = true
+	}
+}
+
+//WithHeaderComparison compares the listed response headers, or all of them if none are listed. Content-Type is
+//always compared. Headers are reported in the "Name: value" form after the status and before the body diffs.
+func WithHeaderComparison(headers ...string) Option {
+	return func(c *Comparator) {
+		c.compareHeaders = true
+		for _, header := range headers {
+			c.headers = append(c.headers, http.CanonicalHeaderKey(header))
+		}
+	}
+}
+
+//WithIgnoredHeaders excludes the headers from the header comparison, for example Date or X-Request-Id.
+func WithIgnoredHeaders(headers ...string) Option {
+	return func(c *Comparator) {
+		if c.ignoredHeaders == nil {
+			c.ignoredHeaders = make(map[string]bool)
+		}
+		for _, header := range headers {
+			c.ignoredHeaders[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+}
+
+//WithMetadataOnly skips the body comparison, so that only the status, headers and cookies are compared if they
+//are enabled.
+func WithMetadataOnly() Option {
+	return func(c *Comparator) {
+		c.metadataOnly = true
+	}
+}
+
+//WithIgnoredPaths excludes json values matching the patterns from the comparison. Patterns are either JSONPath
+//($.meta.requestId, $.items[*].updatedAt, $..traceId) or JSON Pointer with wildcards (/data/*/updatedAt).
+//Matching object members are removed and matching array elements are masked with null before diffing. An invalid
+//pattern is reported by every comparison.
+func WithIgnoredPaths(patterns ...string) Option {
+	return func(c *Comparator) {
+		for _, pattern := range patterns {
+			path, err := compileJSONPath(pattern)
+			if err != nil {
+				c.setErr(err)
+				continue
+			}
+			c.ignoredPaths = append(c.ignoredPaths, path)
+		}
+	}
+}
+
+//WithSimilarityThreshold considers texts equal if they are at least the threshold similar, from 0 to 1 like 0.98
+//for 98 percent, so that tiny copy edits of prose don't flag whole pages. The similarity is one minus the Levenshtein
+//distance of the diffmatchpatch diff of the texts relative to the longer one. Scopes limit the threshold to the html
+//elements compared by the same selectors, to the json strings matching the JSONPath or JSON Pointer patterns, to "body"
+//for text bodies or to "pdf" for the pages of pdf documents, the threshold applies to all of them without scopes. The
+//first added threshold in scope of a text applies.
+func WithSimilarityThreshold(threshold float64, scopes ...string) Option {
+	return func(c *Comparator) {
+		if threshold < 0 || threshold > 1 {
+			c.setErr(fmt.Errorf("similarity threshold must be between 0 and 1, got %v", threshold))
+			return
+		}
+		similarity := similarityThreshold{threshold: threshold}
+		for _, scope := range scopes {
+			if isJSONScope(scope) {
+				path, err := compileJSONPath(scope)
+				if err != nil {
+					c.setErr(err)
+					return
+				}
+				similarity.paths = append(similarity.paths, path)
+				continue
+			}
+			if similarity.scopes == nil {
+				similarity.scopes = make(map[string]bool)
+			}
+			similarity.scopes[scope] = true
+		}
+		c.similarities = append(c.similarities, similarity)
+	}
+}
+
+//WithNumericTolerance considers json numbers equal if they differ by no more than epsilon.
+func WithNumericTolerance(epsilon float64) Option {
+	return func(c *Comparator) {
+		c.numericTolerance = epsilon
+	}
+}
+
+//WithTypeCoercion considers numeric json strings equal to the numbers they represent, so that "5" and 5 are not
+//reported. The numeric tolerance applies to the coerced values too.
+func WithTypeCoercion() Option {
+	return func(c *Comparator) {
+		c.coerceTypes = true
+	}
+}
+
+//WithUnorderedArrays compares json arrays as multisets, so that elements returned in a different order are not
+//reported. Arrays with a key set by WithArrayKeys are paired by the key instead.
+func WithUnorderedArrays() Option {
+	return func(c *Comparator) {
+		c.unorderedArrays = true
+	}
+}
+
+//WithArrayKeys pairs elements of json arrays by the value of a key member, so that every element is diffed with
+//its counterpart regardless of the order. Keys of the map are JSONPath or JSON Pointer patterns of the arrays
+//(like $.items or /data/*/tags), values are names of the key members (like id). Paired elements are addressed by
+//the key instead of the index, like /items/42, and elements without a counterpart are reported as added or
+//removed. Arrays with elements lacking the key or with duplicate keys are compared as usual.
+func WithArrayKeys(keys map[string]string) Option {
+	return func(c *Comparator) {
+		patterns := make([]string, 0, len(keys))
+		for pattern := range keys {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			path, err := compileJSONPath(pattern)
+			if err != nil {
+				c.setErr(err)
+				continue
+			}
+			c.arrayKeys = append(c.arrayKeys, arrayKey{path, keys[pattern]})
+		}
+	}
+}
+
+//WithMode sets the comparison mode used when no html elements are provided instead of selecting it by the
+//content type.
+func WithMode(mode Mode) Option {
+	return func(c *Comparator) {
+		c.mode = mode
+	}
+}
+
+//WithImageDiffOutput writes a png image highlighting the differing pixels to w for every comparison of images
+//with differences. The writer is shared by concurrent comparisons.
+func WithImageDiffOutput(w io.Writer) Option {
+	return func(c *Comparator) {
+		c.imageDiff = w
+	}
+}
+
+//WithHTMLStructure compares element trees of the selected html elements in addition to their text, so changes of
+//tags, attributes and nesting are reported too.
+func WithHTMLStructure() Option {
+	return func(c *Comparator) {
+		c.htmlStructure = true
+	}
+}
+
+//WithXPathSelectors treats all the compared elements as xpath expressions instead of css selectors.
+func WithXPathSelectors() Option {
+	return func(c *Comparator) {
+		c.xpathSelectors = true
+	}
+}
+
+//WithNormalization normalizes text of html elements, attributes and text bodies before they are compared, like
+//WithNormalization(CollapseWhitespace | IgnoreCase).
+func WithNormalization(normalizations Normalization) Option {
+	return func(c *Comparator) {
+		c.normalizations |= normalizations
+	}
+}
+
+//WithGranularity sets the unit of the text differences. Word and line differences are easier to read for large
+//bodies than the default character ones.
+func WithGranularity(granularity Granularity) Option {
+	return func(c *Comparator) {
+		c.granularity = granularity
+	}
+}
+
+//WithParallelDiff diffs texts larger than the size in bytes, 1 MiB if the size is not positive, by chunks in parallel
+//instead of as a whole, which takes long for bodies of several megabytes. The texts are split on lines occurring
+//once in both of them, so the diffs of a chunk may be less minimal than of the whole texts when content moved
+//across the chunks. Smaller texts and texts without such lines are still diffed as a whole.
+func WithParallelDiff(size int) Option {
+	return func(c *Comparator) {
+		if size <= 0 {
+			size = defaultParallelDiffSize
+		}
+		c.parallelDiffSize = size
+	}
+}
+
+//WithEqualSegments includes the unchanged text around the text differences as Equal diffs, so that renderers can
+//show the changes in context or side by side. Only the context lines next to the changes are included, the
+//unchanged lines between are left out, a negative number includes all unchanged text. Json differences are not
+//affected.
+func WithEqualSegments(contextLines int) Option {
+	return func(c *Comparator) {
+		c.equalSegments = true
+		c.contextLines = contextLines
+	}
+}
+
+//WithBatchWorkers sets the number of pairs of a batch compared concurrently.
+func WithBatchWorkers(workers int) Option {
+	return func(c *Comparator) {
+		if workers <= 0 {
+			c.setErr(fmt.Errorf("batch workers must be positive, got %d", workers))
+			return
+		}
+		c.batchWorkers = workers
+	}
+}
+
+//WithResponseCache takes the responses of GET and HEAD requests from the cache, fetching every url once for the
+//same headers, authentication and cookies of the side. Cached responses are read whole, so bodies larger than the
+//in-memory limit are not streamed. Share a NewMemoryCache among the comparisons of a run to fetch the baselines
+//once per run.
+func WithResponseCache(cache ResponseCache) Option {
+	return func(c *Comparator) {
+		c.cache = cache
+		c.cacheFlight = &singleflight.Group{}
+	}
+}
+
+//WithRateLimit limits the requests sent to every host by both sides, including retries and the requests of
+//batches, sweeps and crawls, like WithRateLimit(DefaultRateLimit). Redirects followed by a request are not limited
+//separately.
+func WithRateLimit(limit RateLimit) Option {
+	return func(c *Comparator) {
+		if limit.RequestsPerSecond < 0 || limit.Burst < 0 || limit.MaxConcurrent < 0 || limit.Delay < 0 {
+			c.setErr(fmt.Errorf("rate limit must not be negative, got %+v", limit))
+			return
+		}
+		c.hostLimits = newHostLimits(limit)
+	}
+}
+
+//WithFetchTimeout caps the total time of fetching and reading both responses. Responses not fetched in time are
+//reported like the other fetch errors.
+func WithFetchTimeout(timeout time.Duration) Option {
+	return func(c *Comparator) {
+		c.fetchTimeout = timeout
+	}
+}
+
+//WithRetries retries transient fetch failures of both sides by the policy before the responses are compared. The
+//Retry of the side request takes precedence if it retries.
+func WithRetries(policy RetryPolicy) Option {
+	return func(c *Comparator) {
+		if policy.Retries < 0 || policy.Backoff < 0 {
+			c.setErr(fmt.Errorf("retries and backoff must not be negative, got %d and %v", policy.Retries,
+				policy.Backoff))
+			return
+		}
+		c.retry = policy
+	}
+}
+
+//WithPDFMetadata compares the metadata of pdf documents too, like their titles, authors and creation dates.
+func WithPDFMetadata() Option {
+	return func(c *Comparator) {
+		c.pdfMetadata = true
+	}
+}
+
+//WithLinkChecks requests the links compared in the links mode by HEAD and reports the links broken on a side only.
+func WithLinkChecks() Option {
+	return func(c *Comparator) {
+		c.linkChecks = true
+	}
+}
+
+//WithTrackingParams replaces the query parameters stripped from the links compared in the links mode, like
+//"utm_*" matching the parameters by their prefix. No parameters are stripped if none are given.
+func WithTrackingParams(params ...string) Option {
+	return func(c *Comparator) {
+		c.trackingParams = append([]string{}, params...)
+	}
+}
+
+//WithRenderer renders the pages by the renderer instead of fetching them, so that the content rendered by their
+//scripts is compared too. Rendered pages are compared as html responses with the 200 status, selected elements
+//are extracted from the rendered documents.
+func WithRenderer(renderer Renderer) Option {
+	return func(c *Comparator) {
+		c.renderer = renderer
+	}
+}
+
+//WithMaxBodySize bounds the size of the decoded response bodies, so that endpoints streaming huge bodies can't
+//exhaust the memory of batch runs. The policy tells how the bodies exceeding the size are compared.
+func WithMaxBodySize(size int64, policy BodySizePolicy) Option {
+	return func(c *Comparator) {
+		c.maxBodySize = size
+		c.bodySizePolicy = policy
+	}
+}
+
+//WithoutContentDecoding compares the bodies as they were transferred. Compressed bodies are not decompressed and
+//bodies in other charsets than utf-8 are not transcoded. The http client still decompresses gzip bodies it
+//requested compressed itself.
+func WithoutContentDecoding() Option {
+	return func(c *Comparator) {
+		c.rawBodies = true
+	}
+}
+
+//WithMaxInMemory limits the size of the bodies read into memory. Larger bodies are compared as streams of lines
+//regardless of their content type and the compared elements.
+func WithMaxInMemory(size int64) Option {
+	return func(c *Comparator) {
+		c.maxInMemory = size
+	}
+}
+
+//WithRecordKey matches records of newline delimited json bodies, rows of csv bodies and items of paginated
+//collections by the value of the key field or column instead of their position.
+func WithRecordKey(key string) Option {
+	return func(c *Comparator) {
+		c.recordKey = key
+	}
+}
+
+//WithIgnoredColumns skips the columns of csv bodies.
+func WithIgnoredColumns(columns ...string) Option {
+	return func(c *Comparator) {
+		if c.ignoredColumns == nil {
+			c.ignoredColumns = make(map[string]bool, len(columns))
+		}
+		for _, column := range columns {
+			c.ignoredColumns[column] = true
+		}
+	}
+}
+
+//WithProtoMessage decodes protobuf bodies as messages of the type, so that they are compared field by field.
+func WithProtoMessage(message protoreflect.MessageDescriptor) Option {
+	return func(c *Comparator) {
+		c.protoMessage = message
+	}
+}
+
+//WithProtoDescriptorSet decodes protobuf bodies as the named messages found in the serialized
+//google.protobuf.FileDescriptorSet, like the one written by protoc --descriptor_set_out --include_imports.
+func WithProtoDescriptorSet(descriptorSet []byte, messageName string) Option {
+	return func(c *Comparator) {
+		message, err := protoMessageFromSet(descriptorSet, messageName)
+		if err != nil {
+			c.setErr(err)
+			return
+		}
+		c.protoMessage = message
+	}
+}
+
+//WithPerformanceThresholds reports latencies, times to first byte and body sizes of the responses that differ by
+//more than the thresholds.
+func WithPerformanceThresholds(thresholds PerformanceThresholds) Option {
+	return func(c *Comparator) {
+		c.performance = thresholds
+	}
+}
+
+//WithMaxRedirects limits the number of followed redirects, zero doesn't follow redirects at all, so the redirect
+//responses themselves are compared. Redirects are followed by the policy of the http client by default.
+func WithMaxRedirects(hops int) Option {
+	return func(c *Comparator) {
+		if hops < 0 {
+			c.setErr(fmt.Errorf("max redirects must not be negative, got %d", hops))
+			return
+		}
+		c.maxRedirects = hops
+	}
+}
+
+//WithRedirectComparison compares the redirect chains of the responses by the status codes and the Location
+//headers of the hops.
+func WithRedirectComparison() Option {
+	return func(c *Comparator) {
+		c.compareRedirects = true
+	}
+}
+
+//WithIgnoredCookieValues compares cookies as WithCookieComparison does but skips values of the named cookies, like
+//session identifiers, so only their attributes are compared. Values of all cookies are skipped if no names are
+//provided.
+func WithIgnoredCookieValues(names ...string) Option {
+	return func(c *Comparator) {
+		c.compareCookies = true
+		if c.cookieValues == nil {
+			c.cookieValues = make(map[string]bool, len(names))
+		}
+		for _, name := range names {
+			c.cookieValues[name] = true
+		}
+	}
+}
+
+//WithLogger traces the comparisons at the debug level of the logger: the requests sent and the responses received,
+//retries, responses taken from the cache, the body normalizers changing the bodies, the detected modes and the
+//changes found by every stage of the comparison. Nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Comparator) {
+		c.logger = logger
+	}
+}
+
+//WithJSONCanonicalization rewrites json bodies of both sides in the canonical form of RFC 8785 before they are
+//compared, after the other normalizers. Differences of key order, unicode escapes and number formatting are not
+//reported then by any mode or output, including bodies compared as text or by their hashes.
+func WithJSONCanonicalization() Option {
+	return func(c *Comparator) {
+		c.canonicalJSON = true
+	}
+}
+
+//WithRequestHooks applies the hooks to requests of both sides in the provided order before they are sent.
+func WithRequestHooks(hooks ...RequestHook) Option {
+	return func(c *Comparator) {
+		c.requestHooks = append(c.requestHooks, hooks...)
+	}
+}
+
+//WithResponseHooks applies the hooks to responses of both sides in the provided order before they are compared.
+func WithResponseHooks(hooks ...ResponseHook) Option {
+	return func(c *Comparator) {
+		c.responseHooks = append(c.responseHooks, hooks...)
+	}
+}
+
+//WithDiffHooks applies the hooks to the differences in the provided order before they are returned.
+func WithDiffHooks(hooks ...DiffHook) Option {
+	return func(c *Comparator) {
+		c.diffHooks = append(c.diffHooks, hooks...)
+	}
+}
+
+//WithNormalizers applies the normalizers to both bodies in the provided order before they are compared, for
+//example WithNormalizers(MaskTimestamps(), MaskUUIDs()). Bodies larger than the in-memory limit are streamed
+//without normalizers.
+func WithNormalizers(normalizers ...Normalizer) Option {
+	return func(c *Comparator) {
+		c.bodyNormalizers = append(c.bodyNormalizers, normalizers...)
+	}
+}
+
+//WithMasks masks the matches of the patterns of the masks before the bodies are compared. Masks without a scope
+//are applied to the whole bodies like normalizers, in their order among them. Json scoped masks apply to the
+//matching values of every mode compared as json trees, selector scoped masks to the selected elements of html
+//pages. A mask without a pattern or with an invalid scope is reported by every comparison.
+func WithMasks(masks ...Mask) Option {
+	return func(c *Comparator) {
+		for _, mask := range masks {
+			if mask.Pattern == nil {
+				c.setErr(fmt.Errorf("mask %q has no pattern", mask.Scope))
+				continue
+			}
+			switch {
+			case mask.Scope == "":
+				c.bodyNormalizers = append(c.bodyNormalizers, ReplaceRegexp(mask.Pattern, mask.replacement()))
+			case isJSONScope(mask.Scope):
+				path, err := compileJSONPath(mask.Scope)
+				if err != nil {
+					c.setErr(err)
+					continue
+				}
+				c.masks = append(c.masks, scopedMask{mask, path})
+			default:
+				c.masks = append(c.masks, scopedMask{Mask: mask})
+			}
+		}
+	}
+}
+
+//WithMetrics counts comparisons of urls, their differences, fetch errors and latencies of both sides by the metrics.
+//The same metrics may be shared by several comparators.
+func WithMetrics(metrics *Metrics) Option {
+	return func(c *Comparator) {
+		c.metrics = metrics
+	}
+}
+
+//WithSeverityRules classifies the changes of the results by the rules before the default classification, see
+//Result.Severity.
+func WithSeverityRules(rules ...SeverityRule) Option {
+	return func(c *Comparator) {
+		c.severityRules = append(c.severityRules, rules...)
+	}
+}
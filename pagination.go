@@ -0,0 +1,277 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+//defaultMaxPages is the number of pages followed unless configured otherwise.
+const defaultMaxPages = 100
+
+//PaginationStyle tells how the next page of a list endpoint is found.
+type PaginationStyle int8
+
+//Pagination styles.
+const (
+	//PaginateLink follows the url of the rel="next" link of the Link header.
+	PaginateLink PaginationStyle = iota
+	//PaginateCursor passes the cursor found in the body as a query parameter. Cursors that are urls are followed.
+	PaginateCursor
+	//PaginatePage increments the page number query parameter, starting at the number of the first url or 1.
+	PaginatePage
+	//PaginateOffset advances the offset query parameter by the number of the items of every page.
+	PaginateOffset
+)
+
+//Pagination describes how the pages of a list endpoint are followed and where the items are found in them.
+type Pagination struct {
+	Style PaginationStyle
+	//Items is the JSON Pointer of the array of the items of a page, like /data; the page is the array if empty.
+	Items string
+	//Cursor is the JSON Pointer of the cursor of the next page, like /meta/next_cursor.
+	Cursor string
+	//Param is the query parameter of the cursor, page number or offset, "cursor", "page" and "offset" by default.
+	Param string
+	//MaxPages bounds the followed pages, 100 by default.
+	MaxPages int
+}
+
+//linkNext matches the url of the next page in the Link header.
+var linkNext = regexp.MustCompile(`<([^>]*)>[^,]*;\s*rel="?([^",]*\s)?next[\s"]`)
+
+//ComparePaginated compares the paginated collections with the default options.
+func ComparePaginated(ctx context.Context, aURL, bURL string, aPagination, bPagination Pagination) (*Result, error) {
+	return defaultComparator.ComparePaginated(ctx, aURL, bURL, aPagination, bPagination)
+}
+
+//ComparePaginated follows the pages of the list endpoints of both sides, which may paginate differently,
+//accumulates their items and compares the complete collections. The items are keyed by the record key set by
+//WithRecordKey if all of them have unique ones and addressed by it, like /42/name, otherwise the collections are
+//compared as arrays. Following stops at a page without items, including missing or null ones, or without the next
+//page. A side with a next page left after MaxPages pages is compared partially and the result is truncated then.
+//Integers beyond the precision of doubles, like large ids, are compared exactly.
+func (c *Comparator) ComparePaginated(ctx context.Context, aURL, bURL string, aPagination,
+	bPagination Pagination) (*Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.fetchTimeout)
+		defer cancel()
+	}
+	var aItems, bItems []interface{}
+	var aTruncated, bTruncated bool
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		aItems, aTruncated, err = c.collectPages(groupCtx, aURL, &c.aRequest, aPagination)
+		if err != nil {
+			return &FetchError{SideA, aURL, err}
+		}
+		return nil
+	})
+	group.Go(func() error {
+		var err error
+		bItems, bTruncated, err = c.collectPages(groupCtx, bURL, &c.bRequest, bPagination)
+		if err != nil {
+			return &FetchError{SideB, bURL, err}
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	var aCollection, bCollection interface{} = aItems, bItems
+	if aKeyed, bKeyed, ok := c.keyBoth(aItems, bItems); ok {
+		aCollection, bCollection = aKeyed, bKeyed
+	}
+	result, err := c.compareTrees(ctx, []interface{}{aCollection}, []interface{}{bCollection}, "", "")
+	if err != nil {
+		return nil, err
+	}
+	result.truncated = aTruncated || bTruncated
+	c.postprocess(result)
+	return result, nil
+}
+
+//collectPages fetches the pages one by one and accumulates their items. It reports whether a next page was left
+//after the maximal number of pages.
+func (c *Comparator) collectPages(ctx context.Context, pageURL string, request *Request,
+	pagination Pagination) ([]interface{}, bool, error) {
+	maxPages := pagination.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+	items := []interface{}{}
+	for page := 0; pageURL != ""; page++ {
+		if page == maxPages {
+			return items, true, nil
+		}
+		body, header, err := c.getPage(ctx, pageURL, request)
+		if err != nil {
+			return nil, false, err
+		}
+		document, err := decodePage(body)
+		if err != nil {
+			return nil, false, fmt.Errorf("page %s: %v", pageURL, err)
+		}
+		value := resolvePointer(document, pagination.Items)
+		if value == nil {
+			break
+		}
+		pageItems, ok := value.([]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("page %s: no items array at %q", pageURL, pagination.Items)
+		}
+		if len(pageItems) == 0 {
+			break
+		}
+		items = append(items, pageItems...)
+		if pageURL, err = pagination.next(pageURL, header, document, len(pageItems)); err != nil {
+			return nil, false, err
+		}
+	}
+	return items, false, nil
+}
+
+//decodePage decodes the json page with json numbers. Integers beyond the precision of doubles are kept as json
+//numbers, so that they are compared by their digits, other numbers are decoded as float64 as by the json mode.
+func decodePage(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after the page")
+	}
+	return exactNumbers(document), nil
+}
+
+//exactNumbers replaces the json numbers of the value with float64 unless they are integers that doubles don't
+//represent exactly.
+func exactNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err == nil && strconv.FormatFloat(f, 'f', -1, 64) == v.String() ||
+			strings.ContainsAny(v.String(), ".eE") {
+			return f
+		}
+		return v
+	case map[string]interface{}:
+		for name, child := range v {
+			v[name] = exactNumbers(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = exactNumbers(child)
+		}
+	}
+	return value
+}
+
+//getPage fetches the page and reads its body after host substitutions.
+func (c *Comparator) getPage(ctx context.Context, url string, request *Request) ([]byte, http.Header, error) {
+	resp, err := c.get(ctx, url, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("page %s: %s", url, resp.Status)
+	}
+	return c.prepareBody(body), resp.Header, nil
+}
+
+//next returns the url of the page following the page of the url, empty if it is the last one.
+func (p Pagination) next(pageURL string, header http.Header, document interface{}, items int) (string, error) {
+	current, err := url.Parse(pageURL)
+	if err != nil {
+		return "", err
+	}
+	switch p.Style {
+	case PaginateLink:
+		for _, link := range header["Link"] {
+			if match := linkNext.FindStringSubmatch(link + " "); match != nil {
+				return resolveReference(current, match[1])
+			}
+		}
+		return "", nil
+	case PaginateCursor:
+		var cursor string
+		switch value := resolvePointer(document, p.Cursor).(type) {
+		case string:
+			cursor = value
+		case float64:
+			cursor = strconv.FormatFloat(value, 'f', -1, 64)
+		case json.Number:
+			cursor = value.String()
+		}
+		if cursor == "" {
+			return "", nil
+		}
+		if strings.HasPrefix(cursor, "/") || strings.Contains(cursor, "://") {
+			return resolveReference(current, cursor)
+		}
+		return withQueryParam(current, p.param("cursor"), cursor), nil
+	case PaginatePage:
+		page := 1
+		if value := current.Query().Get(p.param("page")); value != "" {
+			if page, err = strconv.Atoi(value); err != nil {
+				return "", fmt.Errorf("page %s: invalid page number %q", pageURL, value)
+			}
+		}
+		return withQueryParam(current, p.param("page"), strconv.Itoa(page+1)), nil
+	case PaginateOffset:
+		offset := 0
+		if value := current.Query().Get(p.param("offset")); value != "" {
+			if offset, err = strconv.Atoi(value); err != nil {
+				return "", fmt.Errorf("page %s: invalid offset %q", pageURL, value)
+			}
+		}
+		return withQueryParam(current, p.param("offset"), strconv.Itoa(offset+items)), nil
+	}
+	return "", fmt.Errorf("unknown pagination style %d", p.Style)
+}
+
+//param returns the configured query parameter or the default one.
+func (p Pagination) param(defaultParam string) string {
+	if p.Param != "" {
+		return p.Param
+	}
+	return defaultParam
+}
+
+//resolveReference resolves the url reference against the url of the current page.
+func resolveReference(current *url.URL, reference string) (string, error) {
+	next, err := current.Parse(reference)
+	if err != nil {
+		return "", err
+	}
+	return next.String(), nil
+}
+
+//withQueryParam returns the url with the query parameter set to the value.
+func withQueryParam(current *url.URL, name, value string) string {
+	next := *current
+	query := next.Query()
+	query.Set(name, value)
+	next.RawQuery = query.Encode()
+	return next.String()
+}
@@ -0,0 +1,69 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+//pagesServer serves pages of two items numbered by the page query parameter, the ids of the b side differ beyond
+//the precision of doubles.
+func pagesServer(pages int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page > pages {
+			fmt.Fprint(w, `{"data":[]}`)
+			return
+		}
+		id := "9007199254740993"
+		if r.URL.Path == "/b" {
+			id = "9007199254740992"
+		}
+		fmt.Fprintf(w, `{"data":[{"id":%s,"page":%d},{"id":1,"page":%d}]}`, id, page, page)
+	}))
+}
+
+func TestComparePaginatedMaxPages(t *testing.T) {
+	server := pagesServer(3)
+	defer server.Close()
+	pagination := Pagination{Style: PaginatePage, Items: "/data", MaxPages: 2}
+
+	result, err := New().ComparePaginated(context.Background(), server.URL+"/a", server.URL+"/a", pagination,
+		pagination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Truncated() {
+		t.Errorf("collections with pages beyond the maximal number are not truncated")
+	}
+	pagination.MaxPages = 4
+	result, err = New().ComparePaginated(context.Background(), server.URL+"/a", server.URL+"/a", pagination,
+		pagination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Truncated() || !result.Equal() {
+		t.Errorf("complete collections: truncated %v, changes %v", result.Truncated(), result.Changes)
+	}
+}
+
+func TestComparePaginatedLargeIDs(t *testing.T) {
+	server := pagesServer(1)
+	defer server.Close()
+	pagination := Pagination{Style: PaginatePage, Items: "/data"}
+
+	result, err := New().ComparePaginated(context.Background(), server.URL+"/a", server.URL+"/b", pagination,
+		pagination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Path != "/0/id" {
+		t.Errorf("ids differing beyond the precision of doubles: %v", result.Changes)
+	}
+}
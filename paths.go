@@ -0,0 +1,108 @@
+package comparator
+
+import (
+	"context"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+)
+
+//CompareChangedPaths compares json responses for the provided urls using the default options and returns sorted
+//json pointers of the added, removed and modified values.
+func CompareChangedPaths(aURL, bURL string) ([]string, error) {
+	return defaultComparator.CompareChangedPaths(aURL, bURL)
+}
+
+//CompareChangedPathsContext is like CompareChangedPaths but fetches the responses with the provided context.
+func CompareChangedPathsContext(ctx context.Context, aURL, bURL string) ([]string, error) {
+	return defaultComparator.CompareChangedPathsContext(ctx, aURL, bURL)
+}
+
+//CompareChangedPaths compares json responses for the provided urls and returns sorted json pointers of the
+//added, removed and modified values without the values themselves. Values of concatenated json streams are
+//addressed by their position, like /1/items/3, a single document is addressed from its root.
+func (c *Comparator) CompareChangedPaths(aURL, bURL string) ([]string, error) {
+	return c.CompareChangedPathsContext(context.Background(), aURL, bURL)
+}
+
+//CompareChangedPathsContext is like CompareChangedPaths but fetches the responses with the provided context.
+func (c *Comparator) CompareChangedPathsContext(ctx context.Context, aURL, bURL string) ([]string, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	aBody, bBody, err := c.getBodies(ctx, aURL, bURL, &c.aRequest, &c.bRequest)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.compareJSONs(ctx, aBody, bBody)
+	if err != nil {
+		return nil, err
+	}
+	c.postprocess(result)
+	paths := make(map[string]bool)
+	for _, change := range result.Changes {
+		paths[change.Path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
+
+//getBodies fetches both bodies concurrently within the fetch timeout. The first fetch error cancels the other
+//fetch and is returned. Host substitutions and normalizers apply to the bodies as to the compared responses.
+func (c *Comparator) getBodies(ctx context.Context, aURL, bURL string, aRequest, bRequest *Request) ([]byte,
+	[]byte, error) {
+	if c.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.fetchTimeout)
+		defer cancel()
+	}
+	var aBody, bBody []byte
+	var aContentType, bContentType string
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		aBody, aContentType, err = c.getBody(groupCtx, aURL, aRequest)
+		if err != nil {
+			return &FetchError{SideA, aURL, err}
+		}
+		return nil
+	})
+	group.Go(func() error {
+		var err error
+		bBody, bContentType, err = c.getBody(groupCtx, bURL, bRequest)
+		if err != nil {
+			return &FetchError{SideB, bURL, err}
+		}
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+	aBody, err := c.normalizeBody(ctx, c.prepareBody(aBody), aContentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	bBody, err = c.normalizeBody(ctx, c.prepareBody(bBody), bContentType)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aBody, bBody, nil
+}
+
+//getBody fetches the body returning it along with its content type.
+func (c *Comparator) getBody(ctx context.Context, url string, request *Request) ([]byte, string, error) {
+	resp, err := c.get(ctx, url, request)
+	if err != nil {
+		return nil, "", err
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
@@ -0,0 +1,34 @@
+package comparator
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestCompareChangedPathsNormalizesBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":1,"name":%q,"generated":"req-%s"}`, r.URL.Path, r.URL.Path[1:])
+	}))
+	defer server.Close()
+	c := New(WithNormalizers(ReplaceRegexp(regexp.MustCompile(`req-\w+`), "req")))
+
+	paths, err := c.CompareChangedPaths(server.URL+"/a", server.URL+"/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/name"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got paths %v, want %v", paths, want)
+	}
+	result, err := c.CompareResult(server.URL+"/a", server.URL+"/b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0].Path != paths[0] {
+		t.Errorf("changes %v disagree with the paths %v", result.Changes, paths)
+	}
+}
@@ -0,0 +1,131 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ledongthuc/pdf"
+)
+
+//pdfMagic starts pdf bodies.
+var pdfMagic = []byte("%PDF-")
+
+//pdfDocument is the text extracted from a pdf body.
+type pdfDocument struct {
+	pages    []string
+	metadata map[string]string
+}
+
+//comparePDFs compares the text extracted from the pages of pdf bodies page by page, so that documents generated
+//again with the same content are equal. Changes are located at "pdf/pages/<page>" counting the pages from 1. Text
+//normalizations and normalizers apply to the text of every page. The metadata of the documents, like their titles
+//or creation dates, is compared at "pdf/metadata/<Key>" only if enabled.
+func (c *Comparator) comparePDFs(aBody, bBody []byte) (*Result, error) {
+	aDocument, err := decodePDF(aBody)
+	if err != nil {
+		return nil, &ParseError{SideA, ModePDF, err}
+	}
+	bDocument, err := decodePDF(bBody)
+	if err != nil {
+		return nil, &ParseError{SideB, ModePDF, err}
+	}
+	result := &Result{}
+	for i := 0; i < len(aDocument.pages) || i < len(bDocument.pages); i++ {
+		path := "pdf/pages/" + strconv.Itoa(i+1)
+		var aText, bText string
+		if i < len(aDocument.pages) {
+			if aText, err = c.normalizePDFText(aDocument.pages[i]); err != nil {
+				return nil, err
+			}
+		}
+		if i < len(bDocument.pages) {
+			if bText, err = c.normalizePDFText(bDocument.pages[i]); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case i >= len(aDocument.pages):
+			result.add(Change{Path: path, Kind: Added, New: bText}, Diff{bText, Insert})
+		case i >= len(bDocument.pages):
+			result.add(Change{Path: path, Kind: Removed, Old: aText}, Diff{aText, Delete})
+		case aText != bText && !c.similarText("pdf", aText, bText):
+			result.add(Change{path, Modified, aText, bText}, c.compareText(aText, bText)...)
+		}
+	}
+	if c.pdfMetadata {
+		compareMetadata(result, aDocument.metadata, bDocument.metadata)
+	}
+	return result, nil
+}
+
+//normalizePDFText applies the text normalizations and the normalizers to the text of a page.
+func (c *Comparator) normalizePDFText(text string) (string, error) {
+	normalized, err := c.normalizeBody(context.Background(), []byte(c.normalize(text)), "text/plain")
+	if err != nil {
+		return "", err
+	}
+	return string(normalized), nil
+}
+
+//compareMetadata compares the entries of the document information dictionaries.
+func compareMetadata(result *Result, aMetadata, bMetadata map[string]string) {
+	keys := make([]string, 0, len(aMetadata)+len(bMetadata))
+	for key := range aMetadata {
+		keys = append(keys, key)
+	}
+	for key := range bMetadata {
+		if _, ok := aMetadata[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		aValue, aOK := aMetadata[key]
+		bValue, bOK := bMetadata[key]
+		path := "pdf/metadata/" + key
+		switch {
+		case !aOK:
+			result.add(Change{Path: path, Kind: Added, New: bValue}, Diff{key + ": " + bValue, Insert})
+		case !bOK:
+			result.add(Change{Path: path, Kind: Removed, Old: aValue}, Diff{key + ": " + aValue, Delete})
+		case aValue != bValue:
+			result.add(Change{path, Modified, aValue, bValue},
+				Diff{key + ": " + aValue, Delete}, Diff{key + ": " + bValue, Insert})
+		}
+	}
+}
+
+//decodePDF extracts the text of the pages and the metadata of the document. Malformed documents make the pdf
+//reader panic, the panic is returned as the error.
+func decodePDF(body []byte) (document *pdfDocument, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			document, err = nil, fmt.Errorf("malformed pdf: %v", r)
+		}
+	}()
+	reader, err := pdf.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+	document = &pdfDocument{metadata: make(map[string]string)}
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			document.pages = append(document.pages, "")
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", i, err)
+		}
+		document.pages = append(document.pages, text)
+	}
+	info := reader.Trailer().Key("Info")
+	for _, key := range info.Keys() {
+		document.metadata[key] = info.Key(key).Text()
+	}
+	return document, nil
+}
@@ -0,0 +1,75 @@
+package comparator
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+//PerformanceThresholds are the allowed relative differences of the b side from the a side, like 0.2 for 20%.
+//Differences exceeding them are reported as changes located at "latency", "ttfb" and "size". Zero disables the
+//check.
+type PerformanceThresholds struct {
+	//Latency includes reading of the body.
+	Latency float64
+	//TTFB is the time until the response headers are received.
+	TTFB float64
+	//Size is the size of the decoded body.
+	Size float64
+}
+
+//meteredBody counts the read bytes and the time spent reading.
+type meteredBody struct {
+	io.ReadCloser
+	size    int64
+	reading time.Duration
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := b.ReadCloser.Read(p)
+	b.reading += time.Since(start)
+	b.size += int64(n)
+	return n, err
+}
+
+//comparePerformance reports latencies and sizes of the responses that differ more than the thresholds allow.
+func (c *Comparator) comparePerformance(aExchange, bExchange *exchange) *Result {
+	result := &Result{}
+	thresholds := c.performance
+	if exceeds(float64(aExchange.latency), float64(bExchange.latency), thresholds.Latency) {
+		addPerformanceChange(result, "latency", aExchange.latency, bExchange.latency,
+			(bExchange.latency - aExchange.latency).Round(time.Millisecond), thresholds.Latency)
+	}
+	if exceeds(float64(aExchange.ttfb), float64(bExchange.ttfb), thresholds.TTFB) {
+		addPerformanceChange(result, "ttfb", aExchange.ttfb, bExchange.ttfb,
+			(bExchange.ttfb - aExchange.ttfb).Round(time.Millisecond), thresholds.TTFB)
+	}
+	if exceeds(float64(aExchange.size), float64(bExchange.size), thresholds.Size) {
+		addPerformanceChange(result, "size", aExchange.size, bExchange.size,
+			fmt.Sprintf("%d bytes", bExchange.size-aExchange.size), thresholds.Size)
+	}
+	return result
+}
+
+//exceeds reports whether b differs from a by more than the threshold relative to a.
+func exceeds(a, b, threshold float64) bool {
+	if threshold <= 0 || a == b {
+		return false
+	}
+	if a == 0 {
+		return true
+	}
+	difference := (b - a) / a
+	return difference > threshold || -difference > threshold
+}
+
+func addPerformanceChange(result *Result, name string, a, b, difference interface{}, threshold float64) {
+	sign := "+"
+	if text := fmt.Sprint(difference); len(text) > 0 && text[0] == '-' {
+		sign = ""
+	}
+	result.add(Change{name, Modified, a, b},
+		Diff{fmt.Sprintf("%s %v", name, a), Delete},
+		Diff{fmt.Sprintf("%s %v (%s%v, exceeds %g%% threshold)", name, b, sign, difference, threshold*100), Insert})
+}
@@ -0,0 +1,72 @@
+package comparator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+//protoJSON renders decoded messages with proto field names and the default values, so that fields set to the
+//defaults are not reported as missing.
+var protoJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+//compareProtobufs decodes both bodies as messages of the configured type and compares them as json trees, fields
+//are addressed by their names, like /items/0/display_name. Bodies are compared as binary if no message type is
+//configured.
+func (c *Comparator) compareProtobufs(ctx context.Context, aBody, bBody []byte) (*Result, error) {
+	if c.protoMessage == nil {
+		return compareBinaries(aBody, bBody), nil
+	}
+	aValue, err := c.decodeProtobuf(aBody)
+	if err != nil {
+		return nil, &ParseError{SideA, ModeProtobuf, err}
+	}
+	bValue, err := c.decodeProtobuf(bBody)
+	if err != nil {
+		return nil, &ParseError{SideB, ModeProtobuf, err}
+	}
+	return c.compareTrees(ctx, []interface{}{aValue}, []interface{}{bValue}, "", "")
+}
+
+func (c *Comparator) decodeProtobuf(body []byte) (interface{}, error) {
+	message := dynamicpb.NewMessage(c.protoMessage)
+	if err := proto.Unmarshal(body, message); err != nil {
+		return nil, fmt.Errorf("protobuf: %v", err)
+	}
+	text, err := protoJSON.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(text, &value)
+	return value, err
+}
+
+//protoMessageFromSet finds the message in the serialized descriptor set, like the one written by
+//protoc --descriptor_set_out --include_imports.
+func protoMessageFromSet(descriptorSet []byte, messageName string) (protoreflect.MessageDescriptor, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(descriptorSet, &set); err != nil {
+		return nil, err
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+	if err != nil {
+		return nil, err
+	}
+	message, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", messageName)
+	}
+	return message, nil
+}
@@ -0,0 +1,168 @@
+package comparator
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+)
+
+//Protocol is the http protocol the requests of a side are sent by.
+type Protocol int8
+
+//Protocols. ProtocolAuto negotiates the protocol as the http client does, HTTP/2 over tls if the server supports
+//it. ProtocolHTTP2 speaks HTTP/2 over tls or with prior knowledge over cleartext connections, ProtocolHTTP3 supports
+//https urls only. Proxies of the client are not used by HTTP/2 and HTTP/3.
+const (
+	ProtocolAuto Protocol = iota
+	ProtocolHTTP1
+	ProtocolHTTP2
+	ProtocolHTTP3
+)
+
+//protocolNames are the names of the protocols as used by command line flags, they are the alpn identifiers.
+var protocolNames = []string{"auto", "http/1.1", "h2", "h3"}
+
+func (p Protocol) String() string {
+	if p >= 0 && int(p) < len(protocolNames) {
+		return protocolNames[p]
+	}
+	return "unknown"
+}
+
+//MarshalText renders the protocol by its name.
+func (p Protocol) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+//UnmarshalText parses the protocol name, "http/2" and "http/3" are accepted too.
+func (p *Protocol) UnmarshalText(text []byte) error {
+	name := strings.ToLower(string(text))
+	switch name {
+	case "http/2":
+		name = "h2"
+	case "http/3":
+		name = "h3"
+	}
+	for i, protocolName := range protocolNames {
+		if name == protocolName {
+			*p = Protocol(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown protocol %q", text)
+}
+
+//dialFunc dials the connections of transports.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+//protocolTransport returns the transport speaking the protocol. The connections are dialed by the dial function,
+//the quic connections of HTTP/3 at the resolved addresses.
+func protocolTransport(base *http.Transport, protocol Protocol, dial dialFunc, resolve map[string]string,
+	tlsConfig *tls.Config) http.RoundTripper {
+	switch protocol {
+	case ProtocolHTTP2:
+		dialTLS := &http2.Transport{
+			TLSClientConfig: tlsConfig,
+			DialTLSContext: func(ctx context.Context, network, address string, config *tls.Config) (net.Conn, error) {
+				conn, err := dial(ctx, network, address)
+				if err != nil {
+					return nil, err
+				}
+				tlsConn := tls.Client(conn, config)
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					conn.Close()
+					return nil, err
+				}
+				return tlsConn, nil
+			},
+		}
+		cleartext := &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, address string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, address)
+			},
+		}
+		return schemeTransport{"https": dialTLS, "http": cleartext}
+	case ProtocolHTTP3:
+		return &http3.Transport{
+			TLSClientConfig: tlsConfig,
+			Dial: func(ctx context.Context, address string, config *tls.Config, quicConfig *quic.Config) (*quic.Conn,
+				error) {
+				return quic.DialAddrEarly(ctx, resolveAddress(resolve, address), config, quicConfig)
+			},
+		}
+	}
+	transport := base.Clone()
+	transport.DialContext = dial
+	transport.TLSClientConfig = tlsConfig
+	if protocol == ProtocolHTTP1 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return transport
+}
+
+//schemeTransport sends the requests by the transports of their url schemes.
+type schemeTransport map[string]http.RoundTripper
+
+func (t schemeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, ok := t[req.URL.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported protocol scheme %q", req.URL.Scheme)
+	}
+	return transport.RoundTrip(req)
+}
+
+//protocolDiffs reports the different protocols the responses were sent by, like "HTTP/1.1" and "HTTP/2.0", located
+//at "protocol".
+func protocolDiffs(aResp, bResp *http.Response) *Result {
+	result := &Result{}
+	if aResp.Proto != bResp.Proto {
+		result.add(Change{"protocol", Modified, aResp.Proto, bResp.Proto}, Diff{"Protocol: " + aResp.Proto, Delete},
+			Diff{"Protocol: " + bResp.Proto, Insert})
+	}
+	return result
+}
+
+//trailerDiffs reports the different trailers of the responses located at "trailer/<Name>", the bodies must have
+//been read. Trailers announced but not sent are missing. Trailers are handled differently by the protocols,
+//HTTP/1.1 sends them only with chunked bodies.
+func (c *Comparator) trailerDiffs(aResp, bResp *http.Response) *Result {
+	result := &Result{}
+	names := make([]string, 0, len(aResp.Trailer)+len(bResp.Trailer))
+	for _, trailer := range []http.Header{aResp.Trailer, bResp.Trailer} {
+		for name := range trailer {
+			if !c.ignoredHeaders[name] && !containsString(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		aValue, bValue := strings.Join(aResp.Trailer[name], ", "), strings.Join(bResp.Trailer[name], ", ")
+		aOK, bOK := len(aResp.Trailer[name]) > 0, len(bResp.Trailer[name]) > 0
+		if aValue == bValue && aOK == bOK {
+			continue
+		}
+		change := Change{Path: "trailer/" + name, Kind: changeKind(aOK, bOK)}
+		var diffs []Diff
+		if aOK {
+			change.Old = aValue
+			diffs = append(diffs, Diff{name + ": " + aValue, Delete})
+		}
+		if bOK {
+			change.New = bValue
+			diffs = append(diffs, Diff{name + ": " + bValue, Insert})
+		}
+		result.add(change, diffs...)
+	}
+	return result
+}
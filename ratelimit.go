@@ -0,0 +1,109 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+//RateLimit limits the requests sent to every host separately, so that large batches don't overload the compared
+//hosts. Zero fields don't limit.
+type RateLimit struct {
+	//RequestsPerSecond bounds the rate of the requests to a host.
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"`
+	//Burst is the number of requests that may be sent to a host at once within the rate, 1 by default.
+	Burst int `json:"burst" yaml:"burst"`
+	//MaxConcurrent bounds the number of requests to a host in flight, a request is in flight until the headers of
+	//its response are received, so that both sides may be read at once even from the same host.
+	MaxConcurrent int `json:"max_concurrent" yaml:"max_concurrent"`
+	//Delay is the least time between the starts of successive requests to a host.
+	Delay time.Duration `json:"delay" yaml:"delay"`
+}
+
+//DefaultRateLimit is a polite limit for comparisons against production hosts.
+var DefaultRateLimit = RateLimit{RequestsPerSecond: 10, Burst: 5, MaxConcurrent: 4}
+
+//hostLimits keeps the limiters of the hosts.
+type hostLimits struct {
+	limit RateLimit
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+//hostLimit is the state of the limit of a host.
+type hostLimit struct {
+	limiter *rate.Limiter
+	slots   chan struct{}
+	//next is the earliest start of the next request by the delay.
+	next time.Time
+}
+
+func newHostLimits(limit RateLimit) *hostLimits {
+	return &hostLimits{limit: limit, hosts: make(map[string]*hostLimit)}
+}
+
+//host returns the limit of the host creating it on the first request.
+func (l *hostLimits) host(host string) *hostLimit {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit := l.hosts[host]
+	if limit == nil {
+		limit = &hostLimit{}
+		if l.limit.RequestsPerSecond > 0 {
+			burst := l.limit.Burst
+			if burst <= 0 {
+				burst = 1
+			}
+			limit.limiter = rate.NewLimiter(rate.Limit(l.limit.RequestsPerSecond), burst)
+		}
+		if l.limit.MaxConcurrent > 0 {
+			limit.slots = make(chan struct{}, l.limit.MaxConcurrent)
+		}
+		l.hosts[host] = limit
+	}
+	return limit
+}
+
+//acquire waits until a request may be sent to the host and returns the function releasing it. Nil limits don't
+//wait.
+func (l *hostLimits) acquire(ctx context.Context, host string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	limit := l.host(host)
+	release := func() {}
+	if limit.slots != nil {
+		select {
+		case limit.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("rate limit %s: %w", host, ctx.Err())
+		}
+		var once sync.Once
+		release = func() {
+			once.Do(func() { <-limit.slots })
+		}
+	}
+	if limit.limiter != nil {
+		if err := limit.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, fmt.Errorf("rate limit %s: %w", host, err)
+		}
+	}
+	if l.limit.Delay > 0 {
+		l.mu.Lock()
+		start := time.Now()
+		if limit.next.After(start) {
+			start = limit.next
+		}
+		limit.next = start.Add(l.limit.Delay)
+		l.mu.Unlock()
+		if err := sleep(ctx, time.Until(start)); err != nil {
+			release()
+			return nil, fmt.Errorf("rate limit %s: %w", host, err)
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,28 @@
+package comparator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchTimeoutWhileRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":1}`)
+	}))
+	defer server.Close()
+	c := New(WithFetchTimeout(50*time.Millisecond), WithRateLimit(RateLimit{Delay: time.Second}))
+
+	result, err := c.CompareResult(server.URL+"/a", server.URL+"/b", nil)
+	if err != nil {
+		t.Fatalf("CompareResult returned error %v", err)
+	}
+	errs := result.FetchErrors()
+	if len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Fatalf("unexpected fetch errors %v", errs)
+	}
+}
@@ -0,0 +1,75 @@
+package comparator
+
+import (
+	"net/http"
+	"strconv"
+)
+
+//redirectClient returns the client following redirects by the redirect policy. The configured client is not
+//modified, a copy of it with the policy is used instead. Redirects beyond the hops limit are not followed and the
+//last redirect response is compared.
+func (c *Comparator) redirectClient() *http.Client {
+	if c.maxRedirects < 0 {
+		return c.client
+	}
+	client := *c.client
+	maxRedirects := c.maxRedirects
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+	return &client
+}
+
+//redirectChain returns the redirect responses that led to the response, the first redirect first.
+func redirectChain(resp *http.Response) []*http.Response {
+	var chain []*http.Response
+	for previous := resp.Request; previous != nil && previous.Response != nil; previous = previous.Response.Request {
+		chain = append([]*http.Response{previous.Response}, chain...)
+	}
+	if resp.Header.Get("Location") != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		chain = append(chain, resp)
+	}
+	return chain
+}
+
+//compareRedirectChains reports different hops of the redirect chains by their status codes and Location
+//headers located at "redirect/<hop>", hops are counted from zero. Host substitutions apply to the locations.
+func (c *Comparator) compareRedirectChains(aResp, bResp *http.Response) *Result {
+	result := &Result{}
+	aChain := redirectChain(aResp)
+	bChain := redirectChain(bResp)
+	for i := 0; i < len(aChain) || i < len(bChain); i++ {
+		aHop, aOK := c.redirectHop(aChain, i)
+		bHop, bOK := c.redirectHop(bChain, i)
+		if aOK && bOK && aHop == bHop {
+			continue
+		}
+		change := Change{Path: "redirect/" + strconv.Itoa(i), Kind: changeKind(aOK, bOK)}
+		var diffs []Diff
+		if aOK {
+			change.Old = aHop
+			diffs = append(diffs, Diff{"Redirect: " + aHop, Delete})
+		}
+		if bOK {
+			change.New = bHop
+			diffs = append(diffs, Diff{"Redirect: " + bHop, Insert})
+		}
+		result.add(change, diffs...)
+	}
+	return result
+}
+
+//redirectHop renders the hop as the status code followed by the location.
+func (c *Comparator) redirectHop(chain []*http.Response, i int) (string, bool) {
+	if i >= len(chain) {
+		return "", false
+	}
+	location := chain[i].Header.Get("Location")
+	if c.hostReplacer != nil {
+		location = c.hostReplacer.Replace(location)
+	}
+	return strconv.Itoa(chain[i].StatusCode) + " " + location, true
+}
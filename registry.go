@@ -0,0 +1,82 @@
+package comparator
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//BodyComparator compares bodies of a format the package doesn't know. Changes are added to the result with
+//Result.Add. It must be safe for concurrent use.
+type BodyComparator func(ctx context.Context, aBody, bBody []byte) (*Result, error)
+
+//urlComparator is a body comparator registered for urls matching the pattern.
+type urlComparator struct {
+	pattern *regexp.Regexp
+	compare BodyComparator
+}
+
+//registry holds the body comparators registered for all comparators.
+var registry struct {
+	sync.RWMutex
+	contentTypes map[string]BodyComparator
+	urls         []urlComparator
+}
+
+//RegisterComparator registers the body comparator for the media type like "application/vnd.ms-excel" or for the
+//structured syntax suffix like "+cbor". Bodies of the content type are compared by it instead of the built-in
+//modes unless the mode is set explicitly. A later registration for the same content type replaces the earlier one.
+func RegisterComparator(contentType string, compare BodyComparator) {
+	registry.Lock()
+	defer registry.Unlock()
+	if registry.contentTypes == nil {
+		registry.contentTypes = make(map[string]BodyComparator)
+	}
+	registry.contentTypes[mediaTypeOf(contentType)] = compare
+}
+
+//RegisterURLComparator registers the body comparator for the responses with urls of either side matching the
+//pattern. It takes precedence over the content type comparators and the modes, the earliest matching registration
+//is used.
+func RegisterURLComparator(pattern *regexp.Regexp, compare BodyComparator) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.urls = append(registry.urls, urlComparator{pattern, compare})
+}
+
+//registeredComparator finds the body comparator registered for the urls, then for the content types and finally
+//for the content types sniffed from the bodies. Content types are only consulted in the auto mode.
+func (c *Comparator) registeredComparator(aURL, bURL, aContentType, bContentType string, aBody, bBody []byte,
+	mode Mode) (BodyComparator, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, registered := range registry.urls {
+		if aURL != "" && registered.pattern.MatchString(aURL) || bURL != "" && registered.pattern.MatchString(bURL) {
+			return registered.compare, true
+		}
+	}
+	if mode != ModeAuto || len(registry.contentTypes) == 0 {
+		return nil, false
+	}
+	contentTypes := []string{aContentType, bContentType}
+	if aContentType == "" && bContentType == "" {
+		contentTypes = []string{http.DetectContentType(aBody), http.DetectContentType(bBody)}
+	}
+	for _, contentType := range contentTypes {
+		mediaType := mediaTypeOf(contentType)
+		if mediaType == "" {
+			continue
+		}
+		if compare, ok := registry.contentTypes[mediaType]; ok {
+			return compare, true
+		}
+		if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+			if compare, ok := registry.contentTypes[mediaType[i:]]; ok {
+				return compare, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,41 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+)
+
+//Renderer renders the page requested by the request into html, like a headless browser executing the scripts of
+//the page. It must be safe for concurrent use. The headless package implements it by chrome.
+type Renderer interface {
+	Render(ctx context.Context, req *http.Request) ([]byte, error)
+}
+
+//RendererFunc adapts a function to the Renderer interface.
+type RendererFunc func(ctx context.Context, req *http.Request) ([]byte, error)
+
+//Render calls the function.
+func (f RendererFunc) Render(ctx context.Context, req *http.Request) ([]byte, error) {
+	return f(ctx, req)
+}
+
+//render renders the page and describes it as a successful html response.
+func (c *Comparator) render(ctx context.Context, req *http.Request) (*http.Response, error) {
+	page, err := c.renderer.Render(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(page)),
+		ContentLength: int64(len(page)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package comparator
+
+import (
+	"context"
+	"time"
+)
+
+//Report is a json serializable description of a comparison of the responses.
+type Report struct {
+	A        SideReport `json:"a"`
+	B        SideReport `json:"b"`
+	Started  time.Time  `json:"started"`
+	Finished time.Time  `json:"finished"`
+	Equal    bool       `json:"equal"`
+	//Similarity is the similarity of the bodies in percent, see Result.Similarity.
+	Similarity float64  `json:"similarity"`
+	Changes    []Change `json:"changes"`
+	//Error is the error returned along with the result, like the exceeded latency budget.
+	Error string `json:"error,omitempty"`
+}
+
+//SideReport describes the response of one side.
+type SideReport struct {
+	URL string `json:"url"`
+	//Status is the status code, it is zero if the response could not be fetched.
+	Status int `json:"status,omitempty"`
+	//LatencyMS includes reading of the body, TTFBMS is the time until the response headers are received.
+	LatencyMS float64 `json:"latency_ms"`
+	TTFBMS    float64 `json:"ttfb_ms"`
+	//Size is the size of the decoded body.
+	Size int64 `json:"size"`
+	//Error is the reason the response could not be fetched.
+	Error string `json:"error,omitempty"`
+}
+
+//CompareReport is like CompareResult but describes the comparison with the report.
+func CompareReport(aURL, bURL string, compareElements []string) (*Report, error) {
+	return defaultComparator.CompareReport(aURL, bURL, compareElements)
+}
+
+//CompareReportContext is like CompareResultContext but describes the comparison with the report.
+func CompareReportContext(ctx context.Context, aURL, bURL string, compareElements []string) (*Report, error) {
+	return defaultComparator.CompareReportContext(ctx, aURL, bURL, compareElements)
+}
+
+//CompareReport is like CompareResult but describes the comparison with the report.
+func (c *Comparator) CompareReport(aURL, bURL string, compareElements []string) (*Report, error) {
+	return c.CompareReportContext(context.Background(), aURL, bURL, compareElements)
+}
+
+//CompareReportContext is like CompareResultContext but describes the comparison with the report. The report is
+//returned along with the error if the comparison finished, like for the exceeded latency budget.
+func (c *Comparator) CompareReportContext(ctx context.Context, aURL, bURL string, compareElements []string) (
+	*Report, error) {
+	started := time.Now()
+	result, aExchange, bExchange, err := c.compareURLs(ctx, aURL, bURL, compareElements)
+	if result == nil {
+		return nil, err
+	}
+	report := &Report{
+		A:          aExchange.report(),
+		B:          bExchange.report(),
+		Started:    started,
+		Finished:   time.Now(),
+		Equal:      result.Equal(),
+		Similarity: result.Similarity(),
+		Changes:    result.Changes,
+	}
+	if report.Changes == nil {
+		report.Changes = []Change{}
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report, err
+}
+
+func (e *exchange) report() SideReport {
+	side := SideReport{
+		URL:       e.url,
+		Status:    e.status,
+		LatencyMS: e.latency.Seconds() * 1000,
+		TTFBMS:    e.ttfb.Seconds() * 1000,
+		Size:      e.size,
+	}
+	if e.err != nil {
+		side.Error = e.err.Error()
+	}
+	return side
+}
@@ -0,0 +1,156 @@
+package comparator
+
+import "fmt"
+
+//ChangeKind is a kind of the structured change.
+type ChangeKind int8
+
+//Change kinds. Moved changes come in pairs reported by the move detection instead of a removed and an added change
+//of the same value, the change located where the value was moved from has the Old value and the one located where
+//it was moved to the New value.
+const (
+	Added ChangeKind = iota + 1
+	Removed
+	Modified
+	Moved
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	case Moved:
+		return "moved"
+	}
+	return "unknown"
+}
+
+//MarshalText renders the kind by its name.
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+//UnmarshalText parses the kind name.
+func (k *ChangeKind) UnmarshalText(text []byte) error {
+	for kind := Added; kind <= Moved; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown change kind %q", text)
+}
+
+//Change is a structured difference between the responses.
+type Change struct {
+	//Path locates the change. It is a json pointer for json bodies, a css selector for html elements, "status",
+	//"header/<Name>" or "cookie/<name>[/<Attribute>]" for the response metadata and "body" or "body/<detail>" for
+	//text and binary bodies, "image/<detail>" for images and "pdf/<detail>" for pdf documents.
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	//Old is nil for added values, New is nil for removed ones.
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+//Result is a structured result of a comparison.
+type Result struct {
+	Changes []Change
+	diffs   []Diff
+	//bodySize is the size of both compared bodies.
+	bodySize      int64
+	severityRules []SeverityRule
+	fetchErrors   []*FetchError
+	truncated     bool
+}
+
+//Diffs renders the result in the flat form returned by Compare.
+func (r *Result) Diffs() []Diff {
+	return r.diffs
+}
+
+//Equal reports whether both sides were fetched and no differences were found. Sides failing the same way are not
+//equal, their failures are told by FetchErrors.
+func (r *Result) Equal() bool {
+	return len(r.fetchErrors) == 0 && len(r.Changes) == 0 && len(r.diffs) == 0
+}
+
+//FetchErrors returns the errors of the sides that could not be fetched. The errors are reported as the "status"
+//change too.
+func (r *Result) FetchErrors() []*FetchError {
+	return r.fetchErrors
+}
+
+//SideAOnly reports whether only the a side was fetched, so that the b endpoint being down is told from its content
+//being changed.
+func (r *Result) SideAOnly() bool {
+	return len(r.fetchErrors) == 1 && r.fetchErrors[0].Side == SideB
+}
+
+//SideBOnly reports whether only the b side was fetched.
+func (r *Result) SideBOnly() bool {
+	return len(r.fetchErrors) == 1 && r.fetchErrors[0].Side == SideA
+}
+
+//Truncated reports whether the bodies were compared truncated to the maximal body size, or the paginated
+//collections without the pages beyond the maximal number of pages.
+func (r *Result) Truncated() bool {
+	return r.truncated
+}
+
+//Add appends the change along with its flat diffs, the diffs render the change in the form returned by Compare. It
+//is meant for results of body comparators.
+func (r *Result) Add(change Change, diffs ...Diff) {
+	r.add(change, diffs...)
+}
+
+//add appends the change along with its flat diffs.
+func (r *Result) add(change Change, diffs ...Diff) {
+	r.Changes = append(r.Changes, change)
+	r.diffs = append(r.diffs, diffs...)
+}
+
+//merge appends changes and diffs of the other result.
+func (r *Result) merge(other *Result) {
+	r.Changes = append(r.Changes, other.Changes...)
+	r.diffs = append(r.diffs, other.diffs...)
+	r.bodySize += other.bodySize
+	r.truncated = r.truncated || other.truncated
+}
+
+//Similarity estimates how similar the compared bodies are in percent, from 0 for completely different bodies to
+//100 for equal ones. It is the share of the bodies not covered by the inserted and deleted text of the diffs, so
+//it is only as precise as the diffs of the mode. A result without compared bodies is either 100 or 0 similar.
+func (r *Result) Similarity() float64 {
+	if r.bodySize == 0 {
+		if r.Equal() {
+			return 100
+		}
+		return 0
+	}
+	var changed int64
+	for _, diff := range r.diffs {
+		if diff.Type == Insert || diff.Type == Delete {
+			changed += int64(len(diff.Text))
+		}
+	}
+	if changed >= r.bodySize {
+		return 0
+	}
+	return 100 * float64(r.bodySize-changed) / float64(r.bodySize)
+}
+
+//changeKind returns the kind of a change between the values where a missing value is reported by ok flag.
+func changeKind(aOK, bOK bool) ChangeKind {
+	switch {
+	case !aOK:
+		return Added
+	case !bOK:
+		return Removed
+	}
+	return Modified
+}
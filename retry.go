@@ -0,0 +1,59 @@
+package comparator
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+//defaultRetryStatuses are the status codes retried unless the policy lists them.
+var defaultRetryStatuses = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+//RetryPolicy describes retries of transient fetch failures. The zero value doesn't retry.
+type RetryPolicy struct {
+	//Retries is the number of retries after the first attempt.
+	Retries int
+	//Backoff is the delay before the first retry, it doubles for every next one up to MaxBackoff if it is set.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	//Statuses are the retried response status codes, 502, 503 and 504 if empty. Fetch errors are always retried.
+	Statuses []int
+}
+
+//retryable reports whether the outcome of the attempt is a transient failure.
+func (p *RetryPolicy) retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	statuses := p.Statuses
+	if len(statuses) == 0 {
+		statuses = defaultRetryStatuses
+	}
+	for _, status := range statuses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+//next returns the delay before the retry following the one delayed by backoff.
+func (p *RetryPolicy) next(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
+
+//sleep waits for the duration or until the context is done returning its error.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,32 @@
+package comparator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchTimeoutDuringRetryBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	c := New(WithFetchTimeout(50*time.Millisecond), WithRetries(RetryPolicy{Retries: 3, Backoff: time.Second}))
+
+	result, err := c.CompareResult(server.URL+"/a", server.URL+"/b", nil)
+	if err != nil {
+		t.Fatalf("CompareResult returned error %v", err)
+	}
+	errs := result.FetchErrors()
+	if len(errs) != 2 {
+		t.Fatalf("unexpected fetch errors %v", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("fetch error %v is not the exceeded deadline", err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//Runner compares the pairs repeatedly as a parity monitor. Set the fields before Run is called, they must not be
+//changed while it runs.
+type Runner struct {
+	//Comparator compares the pairs, the default one is used if it is nil.
+	Comparator *Comparator
+	Pairs      []Pair
+	//Interval is the delay between the rounds of comparisons, a random delay up to Jitter is added to it.
+	Interval time.Duration
+	Jitter   time.Duration
+	//OnDiff is called when a pair starts to differ or to fail, OnResolved when it is equal again. The callbacks are
+	//called from the goroutine of Run one by one.
+	OnDiff     func(PairStatus)
+	OnResolved func(PairStatus)
+	//Sinks receive the pairs that started to differ or to fail in a round, errors of the sinks are ignored.
+	Sinks []Sink
+
+	mu       sync.Mutex
+	statuses []PairStatus
+}
+
+//PairStatus is the outcome of the last comparison of a pair of the runner.
+type PairStatus struct {
+	Pair    Pair
+	Result  *Result
+	Err     error
+	Checked time.Time
+	//Failures counts the consecutive comparisons that differed or failed, it is zero for an equal pair.
+	Failures int
+}
+
+//Failed reports whether the last comparison differed or failed.
+func (s *PairStatus) Failed() bool {
+	return s.Err != nil || s.Result == nil || !s.Result.Equal()
+}
+
+//Run compares the pairs right away and then after every interval until the context is done. The context error is
+//returned unless the runner is misconfigured.
+func (r *Runner) Run(ctx context.Context) error {
+	c := r.Comparator
+	if c == nil {
+		c = defaultComparator
+	}
+	if c.err != nil {
+		return c.err
+	}
+	if r.Interval <= 0 {
+		return fmt.Errorf("runner interval must be positive, got %v", r.Interval)
+	}
+	for {
+		r.update(ctx, c.CompareBatch(ctx, r.Pairs))
+		delay := r.Interval
+		if r.Jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(r.Jitter)))
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+//Statuses returns the statuses of the pairs after the last round, it is empty before the first one.
+func (r *Runner) Statuses() []PairStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PairStatus(nil), r.statuses...)
+}
+
+//update records the results of the round and notifies the changes of the pairs. Results interrupted by the done
+//context are not recorded.
+func (r *Runner) update(ctx context.Context, results []BatchResult) {
+	r.mu.Lock()
+	previous := r.statuses
+	statuses := make([]PairStatus, len(results))
+	var changed []PairStatus
+	for i, result := range results {
+		status := PairStatus{Pair: result.Pair, Result: result.Result, Err: result.Err, Checked: time.Now()}
+		var last *PairStatus
+		if i < len(previous) {
+			last = &previous[i]
+		}
+		if result.Err == context.Canceled || result.Err == context.DeadlineExceeded {
+			if last != nil {
+				status = *last
+			}
+			statuses[i] = status
+			continue
+		}
+		if status.Failed() {
+			status.Failures = 1
+			if last != nil {
+				status.Failures += last.Failures
+			}
+		}
+		if lastFailed := last != nil && last.Failed(); status.Failed() != lastFailed {
+			changed = append(changed, status)
+		}
+		statuses[i] = status
+	}
+	r.statuses = statuses
+	r.mu.Unlock()
+	var findings []BatchResult
+	for _, status := range changed {
+		if status.Failed() {
+			findings = append(findings, BatchResult{status.Pair, status.Result, status.Err})
+		}
+		if status.Failed() && r.OnDiff != nil {
+			r.OnDiff(status)
+		} else if !status.Failed() && r.OnResolved != nil {
+			r.OnResolved(status)
+		}
+	}
+	Notify(ctx, findings, r.Sinks...)
+}
@@ -0,0 +1,166 @@
+package comparator
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//jsonShape is the structure of json values, the types they have and the shapes of their members and elements.
+type jsonShape struct {
+	//types are the sorted json types of the values: array, boolean, null, number, object or string.
+	types   []string
+	members map[string]*jsonShape
+	//items is the merged shape of the array elements, nil for arrays without elements.
+	items *jsonShape
+}
+
+//shapeOf returns the shape of the json value decoded into the tree form.
+func shapeOf(value interface{}) *jsonShape {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		shape := &jsonShape{types: []string{"object"}, members: make(map[string]*jsonShape, len(v))}
+		for name, member := range v {
+			shape.members[name] = shapeOf(member)
+		}
+		return shape
+	case []interface{}:
+		shape := &jsonShape{types: []string{"array"}}
+		for _, item := range v {
+			shape.items = mergeShapes(shape.items, shapeOf(item))
+		}
+		return shape
+	case string:
+		return &jsonShape{types: []string{"string"}}
+	case bool:
+		return &jsonShape{types: []string{"boolean"}}
+	case nil:
+		return &jsonShape{types: []string{"null"}}
+	}
+	return &jsonShape{types: []string{"number"}}
+}
+
+//mergeShapes returns the shape of values having either shape. Members of objects and elements of arrays are merged
+//recursively.
+func mergeShapes(a, b *jsonShape) *jsonShape {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	merged := &jsonShape{items: mergeShapes(a.items, b.items)}
+	for _, types := range [][]string{a.types, b.types} {
+		for _, name := range types {
+			if !containsString(merged.types, name) {
+				merged.types = append(merged.types, name)
+			}
+		}
+	}
+	sort.Strings(merged.types)
+	if a.members != nil || b.members != nil {
+		merged.members = make(map[string]*jsonShape)
+		for _, members := range []map[string]*jsonShape{a.members, b.members} {
+			for name, member := range members {
+				merged.members[name] = mergeShapes(merged.members[name], member)
+			}
+		}
+	}
+	return merged
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+//String describes the types of the shape, like "string|null".
+func (s *jsonShape) String() string {
+	return strings.Join(s.types, "|")
+}
+
+//compareSchemas compares the structures of json bodies rather than their values: the presence of object members,
+//the types of the values and the shapes of array elements, so that responses of environments serving different
+//data may be checked to keep the same contract. Array elements are merged into a single shape located at
+//"<array>/*", arrays without elements match any shape. Changed types are reported as modified with the types as
+//values, like "number|null", members present on a side only as added or removed. Json ignore rules apply.
+func (c *Comparator) compareSchemas(ctx context.Context, aBody, bBody []byte) (*Result, error) {
+	aValues, _, err := decodeJSONStream(aBody)
+	if err != nil {
+		return nil, &ParseError{SideA, ModeSchema, err}
+	}
+	bValues, _, err := decodeJSONStream(bBody)
+	if err != nil {
+		return nil, &ParseError{SideB, ModeSchema, err}
+	}
+	for _, values := range [][]interface{}{aValues, bValues} {
+		for _, value := range values {
+			for _, path := range c.ignoredPaths {
+				path.strip(value)
+			}
+		}
+	}
+	result := &Result{}
+	stream := len(aValues) > 1 || len(bValues) > 1
+	for i := 0; i < len(aValues) || i < len(bValues); i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		prefix := ""
+		if stream {
+			prefix = "/" + strconv.Itoa(i)
+		}
+		var aShape, bShape *jsonShape
+		if i < len(aValues) {
+			aShape = shapeOf(aValues[i])
+		}
+		if i < len(bValues) {
+			bShape = shapeOf(bValues[i])
+		}
+		compareShapes(result, prefix, aShape, bShape)
+	}
+	return result, nil
+}
+
+//compareShapes reports the differences of the shapes located at the path, nil shapes are missing.
+func compareShapes(result *Result, path string, aShape, bShape *jsonShape) {
+	location := path
+	if location == "" {
+		location = "/"
+	}
+	switch {
+	case aShape == nil:
+		result.add(Change{path, Added, nil, bShape.String()}, Diff{location + ": " + bShape.String(), Insert})
+		return
+	case bShape == nil:
+		result.add(Change{path, Removed, aShape.String(), nil}, Diff{location + ": " + aShape.String(), Delete})
+		return
+	}
+	if aTypes, bTypes := aShape.String(), bShape.String(); aTypes != bTypes {
+		result.add(Change{path, Modified, aTypes, bTypes}, Diff{location + ": " + aTypes, Delete},
+			Diff{location + ": " + bTypes, Insert})
+	}
+	if aShape.members != nil && bShape.members != nil {
+		names := make([]string, 0, len(aShape.members)+len(bShape.members))
+		for name := range aShape.members {
+			names = append(names, name)
+		}
+		for name := range bShape.members {
+			if _, ok := aShape.members[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			compareShapes(result, path+"/"+escapePointerToken(name), aShape.members[name], bShape.members[name])
+		}
+	}
+	if aShape.items != nil && bShape.items != nil {
+		compareShapes(result, path+"/*", aShape.items, bShape.items)
+	}
+}
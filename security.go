@@ -0,0 +1,150 @@
+package comparator
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+//securityHeaders are the security headers compared by the security comparison.
+var securityHeaders = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"Content-Security-Policy-Report-Only",
+	"X-Frame-Options",
+	"X-Content-Type-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+	"Cross-Origin-Opener-Policy",
+	"Cross-Origin-Embedder-Policy",
+	"Cross-Origin-Resource-Policy",
+}
+
+//noTLS describes the connection of a response not fetched over tls.
+const noTLS = "none"
+
+//securityDiffs reports different tls connections and security headers of the responses. The protocol version,
+//the cipher suite, the issuer, the expiry date and the sorted dns names of the leaf certificate are located at
+//"tls/version", "tls/cipher", "tls/issuer", "tls/expiry" and "tls/names". Security headers are located at
+//"security/<Name>", content security policies at "security/<Name>/<directive>" as the order of their directives and
+//sources doesn't matter. Host substitutions apply to the header values.
+func (c *Comparator) securityDiffs(aResp, bResp *http.Response) *Result {
+	result := &Result{}
+	aTLS, bTLS := tlsDetails(aResp.TLS), tlsDetails(bResp.TLS)
+	for _, field := range []struct{ name, label string }{
+		{"version", "TLS version"},
+		{"cipher", "TLS cipher"},
+		{"issuer", "TLS issuer"},
+		{"expiry", "TLS expiry"},
+		{"names", "TLS names"},
+	} {
+		addSecurityChange(result, "tls/"+field.name, field.label, aTLS[field.name], bTLS[field.name])
+	}
+	for _, name := range securityHeaders {
+		aValue, bValue := c.securityHeader(aResp.Header, name), c.securityHeader(bResp.Header, name)
+		if !strings.HasPrefix(name, "Content-Security-Policy") {
+			addSecurityChange(result, "security/"+name, name, aValue, bValue)
+			continue
+		}
+		aPolicy, bPolicy := cspDirectives(aValue), cspDirectives(bValue)
+		for _, directive := range unionKeys(aPolicy, bPolicy) {
+			addSecurityChange(result, "security/"+name+"/"+directive, name+" "+directive, aPolicy[directive],
+				bPolicy[directive])
+		}
+	}
+	return result
+}
+
+//addSecurityChange reports the values if they differ, empty values are missing.
+func addSecurityChange(result *Result, path, label, aValue, bValue string) {
+	if aValue == bValue {
+		return
+	}
+	change := Change{Path: path, Kind: changeKind(aValue != "", bValue != "")}
+	var diffs []Diff
+	if aValue != "" {
+		change.Old = aValue
+		diffs = append(diffs, Diff{label + ": " + aValue, Delete})
+	}
+	if bValue != "" {
+		change.New = bValue
+		diffs = append(diffs, Diff{label + ": " + bValue, Insert})
+	}
+	result.add(change, diffs...)
+}
+
+//tlsDetails describes the tls connection by its compared fields.
+func tlsDetails(state *tls.ConnectionState) map[string]string {
+	if state == nil {
+		return map[string]string{"version": noTLS}
+	}
+	details := map[string]string{
+		"version": tls.VersionName(state.Version),
+		"cipher":  tls.CipherSuiteName(state.CipherSuite),
+	}
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		details["issuer"] = leaf.Issuer.String()
+		details["expiry"] = leaf.NotAfter.UTC().Format("2006-01-02")
+		names := append([]string{}, leaf.DNSNames...)
+		sort.Strings(names)
+		details["names"] = strings.Join(names, ", ")
+	}
+	return details
+}
+
+//securityHeader returns the normalized value of the header. Directives of Strict-Transport-Security are sorted and
+//lower cased as their order and case don't matter.
+func (c *Comparator) securityHeader(header http.Header, name string) string {
+	value := strings.TrimSpace(strings.Join(header[name], ", "))
+	if c.hostReplacer != nil {
+		value = c.hostReplacer.Replace(value)
+	}
+	if name == "Strict-Transport-Security" && value != "" {
+		var directives []string
+		for _, directive := range strings.Split(value, ";") {
+			if directive = strings.ToLower(strings.Join(strings.Fields(directive), "")); directive != "" {
+				directives = append(directives, directive)
+			}
+		}
+		sort.Strings(directives)
+		value = strings.Join(directives, "; ")
+	}
+	return value
+}
+
+//cspDirectives parses the content security policy into its directives with their sorted sources. Directives
+//without sources, like upgrade-insecure-requests, have the value "set". Several policies are merged.
+func cspDirectives(policy string) map[string]string {
+	directives := make(map[string]string)
+	for _, directive := range strings.FieldsFunc(policy, func(r rune) bool { return r == ';' || r == ',' }) {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		sources := append([]string{}, fields[1:]...)
+		sort.Strings(sources)
+		value := strings.Join(sources, " ")
+		if value == "" {
+			value = "set"
+		}
+		directives[strings.ToLower(fields[0])] = value
+	}
+	return directives
+}
+
+//unionKeys returns the sorted keys of both maps.
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,41 @@
+package comparator
+
+import (
+	"sync"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/xpath"
+)
+
+//selectorCache keeps compiled css selectors and xpath expressions so that repeated comparisons with the same
+//Comparator don't parse them again.
+type selectorCache struct {
+	selectors sync.Map
+	xpaths    sync.Map
+}
+
+//compile returns compiled selector, parsing it only on the first request.
+func (s *selectorCache) compile(selector string) (cascadia.Selector, error) {
+	if compiled, ok := s.selectors.Load(selector); ok {
+		return compiled.(cascadia.Selector), nil
+	}
+	compiled, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, err
+	}
+	s.selectors.Store(selector, compiled)
+	return compiled, nil
+}
+
+//compileXPath returns compiled xpath expression, parsing it only on the first request.
+func (s *selectorCache) compileXPath(expression string) (*xpath.Expr, error) {
+	if compiled, ok := s.xpaths.Load(expression); ok {
+		return compiled.(*xpath.Expr), nil
+	}
+	compiled, err := xpath.Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+	s.xpaths.Store(expression, compiled)
+	return compiled, nil
+}
@@ -0,0 +1,95 @@
+package comparator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+//benchmarkSelectors are the compared elements of the selector benchmarks.
+var benchmarkSelectors = []string{"div.product > h2.title", "ul#items li:nth-child(odd) span.price", "footer a[href]"}
+
+//benchmarkPage builds a page with the elements selected by benchmarkSelectors.
+func benchmarkPage(price int) []byte {
+	var page strings.Builder
+	page.WriteString(`<html><body><div class="product"><h2 class="title">Product</h2></div><ul id="items">`)
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&page, `<li><span class="price">%d</span></li>`, price+i)
+	}
+	page.WriteString(`</ul><footer><a href="/about">About</a></footer></body></html>`)
+	return []byte(page.String())
+}
+
+func BenchmarkCompareSelectorsCached(b *testing.B) {
+	aPage, bPage := benchmarkPage(1), benchmarkPage(2)
+	c := New(WithMode(ModeHTML))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.CompareBytesResult(aPage, bPage, benchmarkSelectors); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompareSelectorsUncached(b *testing.B) {
+	aPage, bPage := benchmarkPage(1), benchmarkPage(2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		//every Comparator has its own cache, so the selectors are compiled by every comparison.
+		c := New(WithMode(ModeHTML))
+		if _, err := c.CompareBytesResult(aPage, bPage, benchmarkSelectors); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//benchmarkDocument builds a json document with the values ignored and masked by benchmarkOptions.
+func benchmarkDocument(version int) []byte {
+	var document strings.Builder
+	fmt.Fprintf(&document, `{"meta":{"requestId":"req-%d"},"items":[`, version)
+	for i := 0; i < 50; i++ {
+		if i > 0 {
+			document.WriteString(",")
+		}
+		fmt.Fprintf(&document, `{"id":%d,"updatedAt":"2020-01-0%dT00:00:00Z","token":"tok-%d-%d"}`, i, version, version, i)
+	}
+	document.WriteString("]}")
+	return []byte(document.String())
+}
+
+//benchmarkOptions compiles the JSONPath patterns and the mask regexes of the options benchmarks.
+func benchmarkOptions() []Option {
+	return []Option{
+		WithMode(ModeJSON),
+		WithIgnoredPaths("$.meta.requestId", "$.items[*].updatedAt"),
+		WithMasks(Mask{Pattern: regexp.MustCompile(`tok-\d+-\d+`), Scope: "$..token"}),
+	}
+}
+
+func BenchmarkCompareOptionsReused(b *testing.B) {
+	a, bDocument := benchmarkDocument(1), benchmarkDocument(2)
+	c := New(benchmarkOptions()...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.CompareBytesResult(a, bDocument, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompareOptionsPerCall(b *testing.B) {
+	a, bDocument := benchmarkDocument(1), benchmarkDocument(2)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		//the patterns are compiled by the options, so a new Comparator compiles them for every comparison.
+		c := New(benchmarkOptions()...)
+		if _, err := c.CompareBytesResult(a, bDocument, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package comparator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//Severity tells how meaningful a change is.
+type Severity int8
+
+//Severities from the least meaningful.
+const (
+	SeverityInfo Severity = iota + 1
+	SeverityMinor
+	SeverityMajor
+	SeverityCritical
+)
+
+var severityNames = []string{"", "info", "minor", "major", "critical"}
+
+func (s Severity) String() string {
+	if s > 0 && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return "unknown"
+}
+
+//MarshalText renders the severity by its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+//UnmarshalText parses the severity name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for i := SeverityInfo; i <= SeverityCritical; i++ {
+		if strings.EqualFold(i.String(), string(text)) {
+			*s = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+//SeverityRule classifies the change, false leaves it to the following rules.
+type SeverityRule func(change Change) (Severity, bool)
+
+//PathSeverity classifies the changes with paths matching the pattern.
+func PathSeverity(pattern *regexp.Regexp, severity Severity) SeverityRule {
+	return func(change Change) (Severity, bool) {
+		return severity, pattern.MatchString(change.Path)
+	}
+}
+
+//KindSeverity classifies the changes of the kind.
+func KindSeverity(kind ChangeKind, severity Severity) SeverityRule {
+	return func(change Change) (Severity, bool) {
+		return severity, change.Kind == kind
+	}
+}
+
+//Severity classifies the change by the rules of the comparator that produced the result, the first matching rule
+//wins. Changes not matched by any rule are classified by default: the status is critical, redirects are major,
+//headers, cookies and performance are minor, modifications of text in whitespace only are informational and other
+//changes are major.
+func (r *Result) Severity(change Change) Severity {
+	for _, rule := range r.severityRules {
+		if severity, ok := rule(change); ok {
+			return severity
+		}
+	}
+	switch {
+	case change.Path == "status":
+		return SeverityCritical
+	case strings.HasPrefix(change.Path, "redirect/"):
+		return SeverityMajor
+	case strings.HasPrefix(change.Path, "header/") || strings.HasPrefix(change.Path, "cookie/"):
+		return SeverityMinor
+	case change.Path == "latency" || change.Path == "ttfb" || change.Path == "size":
+		return SeverityMinor
+	case change.Kind == Modified && whitespaceOnly(change.Old, change.New):
+		return SeverityInfo
+	}
+	return SeverityMajor
+}
+
+//MaxSeverity returns the severity of the most meaningful change, zero if there are no changes.
+func (r *Result) MaxSeverity() Severity {
+	var highest Severity
+	for _, change := range r.Changes {
+		if severity := r.Severity(change); severity > highest {
+			highest = severity
+		}
+	}
+	return highest
+}
+
+//Passed reports whether both sides were fetched and all changes are less severe than the threshold, so
+//Passed(SeverityMajor) fails on major and critical changes only.
+func (r *Result) Passed(threshold Severity) bool {
+	return len(r.fetchErrors) == 0 && r.MaxSeverity() < threshold
+}
+
+//whitespaceOnly reports whether the values are texts that differ in whitespace only.
+func whitespaceOnly(a, b interface{}) bool {
+	aText, aOK := a.(string)
+	bText, bOK := b.(string)
+	return aOK && bOK && strings.Join(strings.Fields(aText), "") == strings.Join(strings.Fields(bText), "")
+}
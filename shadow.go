@@ -0,0 +1,169 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+)
+
+//defaultShadowBodySize bounds the buffered primary bodies of shadowed requests if the maximal body size is not set.
+const defaultShadowBodySize = 10 << 20
+
+//hopHeaders are headers of a single connection, they are not mirrored to the candidate backend.
+var hopHeaders = []string{"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer",
+	"Transfer-Encoding", "Upgrade"}
+
+//Mismatch describes a mirrored request whose responses of the primary and the candidate backends differ or could
+//not be compared.
+type Mismatch struct {
+	Method string
+	//URI is the request uri of the incoming request, the path with the query.
+	URI    string
+	Result *Result
+	Err    error
+}
+
+//shadowKey is the context key of the mirrored request.
+type shadowKey struct{}
+
+//shadowRequest is the incoming request as it is mirrored to the candidate backend.
+type shadowRequest struct {
+	method string
+	uri    string
+	header http.Header
+	body   []byte
+}
+
+//ShadowProxy returns a reverse proxy to the primary backend that mirrors the incoming requests to the candidate
+//backend and compares the responses asynchronously, the response of the primary backend is served unchanged. The
+//primary body is streamed to the client and copied up to the maximal body size, 10 MiB if it is not set, the
+//comparison starts once the client has read the whole body. Bodies exceeding the size are compared by the body size
+//policy, those exceeding 10 MiB without the size and those hashed by the policy are reported as BodySizeErrors.
+//Mismatches and comparison errors are reported to the callback, which may be called concurrently. At most the batch
+//workers number of comparisons run concurrently, requests arriving while all of them are busy are not mirrored.
+//Every method is mirrored, so the candidate backend must tolerate the side effects of the requests.
+func (c *Comparator) ShadowProxy(primary, candidate *url.URL, report func(Mismatch)) (*httputil.ReverseProxy,
+	error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	workers := c.batchWorkers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	busy := make(chan struct{}, workers)
+	proxy := httputil.NewSingleHostReverseProxy(primary)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		mirror := &shadowRequest{method: req.Method, uri: req.URL.RequestURI(), header: req.Header.Clone()}
+		for _, name := range hopHeaders {
+			mirror.header.Del(name)
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := ioutil.ReadAll(req.Body)
+			req.Body.Close()
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				director(req)
+				return
+			}
+			mirror.body = body
+		}
+		*req = *req.WithContext(context.WithValue(req.Context(), shadowKey{}, mirror))
+		director(req)
+	}
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		mirror, ok := resp.Request.Context().Value(shadowKey{}).(*shadowRequest)
+		if !ok {
+			return nil
+		}
+		select {
+		case busy <- struct{}{}:
+		default:
+			return nil
+		}
+		status, header := resp.StatusCode, resp.Header.Clone()
+		primaryURL, candidateURL := joinURL(primary.String(), mirror.uri), joinURL(candidate.String(), mirror.uri)
+		limit := c.maxBodySize
+		if limit <= 0 {
+			limit = defaultShadowBodySize
+		}
+		resp.Body = &teeBody{ReadCloser: resp.Body, limit: limit + 1, finish: func(body []byte, complete,
+			exceeded bool) {
+			if !complete {
+				<-busy
+				return
+			}
+			go func() {
+				defer func() { <-busy }()
+				mismatch := Mismatch{Method: mirror.method, URI: mirror.uri}
+				if exceeded && (c.maxBodySize <= 0 || c.bodySizePolicy == BodySizeHash) {
+					mismatch.Err = &BodySizeError{SideA, primaryURL, limit}
+				} else {
+					primaryResp := &http.Response{StatusCode: status, Header: header,
+						Body: ioutil.NopCloser(bytes.NewReader(body))}
+					mismatch.Result, mismatch.Err = c.shadow(primaryResp, primaryURL, candidateURL, mirror)
+				}
+				if mismatch.Err != nil || !mismatch.Result.Equal() {
+					report(mismatch)
+				}
+			}()
+		}}
+		return nil
+	}
+	return proxy, nil
+}
+
+//teeBody copies the body streamed to the client up to the limit. The finish function is called once the body is
+//closed, with the copy and whether the body was read to its end and exceeded the limit.
+type teeBody struct {
+	io.ReadCloser
+	limit    int64
+	buf      bytes.Buffer
+	eof      bool
+	exceeded bool
+	finish   func(body []byte, complete, exceeded bool)
+	once     sync.Once
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if remaining := b.limit - int64(b.buf.Len()); int64(n) > remaining {
+		b.buf.Write(p[:remaining])
+		b.exceeded = true
+	} else {
+		b.buf.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+//Close closes the body and finishes the copy.
+func (b *teeBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.finish(b.buf.Bytes(), b.eof, b.exceeded) })
+	return err
+}
+
+//shadow compares the response of the primary backend with the response of the candidate backend to the mirrored
+//request.
+func (c *Comparator) shadow(primaryResp *http.Response, primaryURL, candidateURL string, mirror *shadowRequest) (
+	*Result, error) {
+	if err := decodeContent(primaryResp); err != nil {
+		return nil, err
+	}
+	body, err := readBody(primaryResp)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{URL: primaryURL, Status: primaryResp.StatusCode, Header: primaryResp.Header, Body: body}
+	request := Request{Method: mirror.method, Header: mirror.header, Body: mirror.body}
+	return c.compareSnapshot(context.Background(), snapshot, candidateURL, &request, nil)
+}
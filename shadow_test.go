@@ -0,0 +1,107 @@
+package comparator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+//shadowBackends starts the primary and the candidate backends and the shadow proxy in front of them, mismatches
+//are sent to the returned channel.
+func shadowBackends(t *testing.T, c *Comparator, primary, candidate http.HandlerFunc) (string, chan Mismatch) {
+	t.Helper()
+	primaryServer := httptest.NewServer(primary)
+	t.Cleanup(primaryServer.Close)
+	candidateServer := httptest.NewServer(candidate)
+	t.Cleanup(candidateServer.Close)
+	primaryURL, _ := url.Parse(primaryServer.URL)
+	candidateURL, _ := url.Parse(candidateServer.URL)
+	mismatches := make(chan Mismatch, 1)
+	proxy, err := c.ShadowProxy(primaryURL, candidateURL, func(mismatch Mismatch) { mismatches <- mismatch })
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyServer := httptest.NewServer(proxy)
+	t.Cleanup(proxyServer.Close)
+	return proxyServer.URL, mismatches
+}
+
+func TestShadowProxyStreamsPrimaryBody(t *testing.T) {
+	release := make(chan struct{})
+	primary := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "first line")
+		w.(http.Flusher).Flush()
+		<-release
+		fmt.Fprintln(w, "second line")
+	}
+	candidate := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "first line\nchanged line\n")
+	}
+	proxyURL, mismatches := shadowBackends(t, New(WithMode(ModeText)), primary, candidate)
+
+	resp, err := http.Get(proxyURL + "/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(resp.Body)
+	read := make(chan string)
+	go func() {
+		line, _ := reader.ReadString('\n')
+		read <- line
+	}()
+	select {
+	case line := <-read:
+		if line != "first line\n" {
+			t.Errorf("got first line %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the primary body is not streamed to the client")
+	}
+	close(release)
+	rest, _ := ioutil.ReadAll(reader)
+	resp.Body.Close()
+	if string(rest) != "second line\n" {
+		t.Errorf("got the rest of the body %q", rest)
+	}
+	select {
+	case mismatch := <-mismatches:
+		if mismatch.Err != nil || mismatch.URI != "/page" || mismatch.Result.Equal() {
+			t.Errorf("unexpected mismatch %+v", mismatch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the different responses are not reported")
+	}
+}
+
+func TestShadowProxyBodySize(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+	handler := func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, body) }
+	proxyURL, mismatches := shadowBackends(t, New(WithMaxBodySize(1024, BodySizeHash)), handler, handler)
+
+	resp, err := http.Get(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(served) != body {
+		t.Errorf("the primary body is not served whole, got %d bytes", len(served))
+	}
+	select {
+	case mismatch := <-mismatches:
+		var sizeErr *BodySizeError
+		if !errors.As(mismatch.Err, &sizeErr) || sizeErr.Limit != 1024 {
+			t.Errorf("got mismatch %+v, want the body size error", mismatch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the body exceeding the size is not reported")
+	}
+}
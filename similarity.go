@@ -0,0 +1,81 @@
+package comparator
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+//similarityThreshold considers the texts of its scopes equal if they are similar enough.
+type similarityThreshold struct {
+	threshold float64
+	//scopes are the html selectors, "body" and "pdf", all texts are in scope if there are none.
+	scopes map[string]bool
+	paths  []jsonPath
+}
+
+//similarity returns how similar the texts are, from 0 for completely different texts to 1 for equal ones, as one
+//minus the Levenshtein distance of their diffmatchpatch diff relative to the length in runes of the longer text.
+func similarity(aText, bText string) float64 {
+	if aText == bText {
+		return 1
+	}
+	length := utf8.RuneCountInString(aText)
+	if bLength := utf8.RuneCountInString(bText); bLength > length {
+		length = bLength
+	}
+	distance := textDiffer.DiffLevenshtein(textDiffer.DiffMain(aText, bText, false))
+	return 1 - float64(distance)/float64(length)
+}
+
+//similarText reports whether the texts of the html element selected by the selector, of the text body for "body"
+//or of the pdf pages for "pdf" are similar enough to be considered equal.
+func (c *Comparator) similarText(scope, aText, bText string) bool {
+	for _, threshold := range c.similarities {
+		if threshold.scopes == nil && threshold.paths == nil || threshold.scopes[scope] {
+			return similarity(aText, bText) >= threshold.threshold
+		}
+	}
+	return false
+}
+
+//similarString reports whether the json strings located by the reference tokens are similar enough to be
+//considered equal.
+func (c *Comparator) similarString(path []string, aText, bText string) bool {
+	for _, threshold := range c.similarities {
+		matches := threshold.scopes == nil && threshold.paths == nil
+		for _, pattern := range threshold.paths {
+			matches = matches || pattern.match(path)
+		}
+		if matches {
+			return similarity(aText, bText) >= threshold.threshold
+		}
+	}
+	return false
+}
+
+//equalizeSimilar replaces the strings of the b tree with the strings at the same positions of the a tree if they
+//are similar enough, members are matched by their names and array elements by their positions. The b tree is
+//modified in place and its possibly replaced root is returned.
+func (c *Comparator) equalizeSimilar(path []string, aValue, bValue interface{}) interface{} {
+	switch b := bValue.(type) {
+	case string:
+		if a, ok := aValue.(string); ok && a != b && c.similarString(path, a, b) {
+			return a
+		}
+	case map[string]interface{}:
+		if a, ok := aValue.(map[string]interface{}); ok {
+			for name, child := range b {
+				if aChild, ok := a[name]; ok {
+					b[name] = c.equalizeSimilar(childPath(path, name), aChild, child)
+				}
+			}
+		}
+	case []interface{}:
+		if a, ok := aValue.([]interface{}); ok {
+			for i := 0; i < len(a) && i < len(b); i++ {
+				b[i] = c.equalizeSimilar(childPath(path, strconv.Itoa(i)), a[i], b[i])
+			}
+		}
+	}
+	return bValue
+}
@@ -0,0 +1,43 @@
+package comparator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"kitten", "kitten", 1},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"abc", "xyz", 0},
+		{"café au lait", "cafe au lait", 1 - 1.0/12},
+	}
+	for _, test := range tests {
+		if got := similarity(test.a, test.b); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("similarity(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestSimilarityThreshold(t *testing.T) {
+	a := "The quick brown fox jumps over the lazy dog near the river bank at dawn."
+	b := "The quick brown fox jumped over the lazy dog near the river bank at dawn."
+	result, err := New(WithMode(ModeText), WithSimilarityThreshold(0.95)).CompareBytesResult([]byte(a), []byte(b), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal() {
+		t.Errorf("similar texts differ: %v", result.Changes)
+	}
+	result, err = New(WithMode(ModeText), WithSimilarityThreshold(0.99)).CompareBytesResult([]byte(a), []byte(b), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Equal() {
+		t.Errorf("texts below the threshold are equal")
+	}
+}
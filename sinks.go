@@ -0,0 +1,190 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	//defaultSlackChanges is the number of changes of a pair listed by slack messages unless configured otherwise.
+	defaultSlackChanges = 5
+	//maxSlackValue is the length at which values of the changes are truncated in slack messages.
+	maxSlackValue = 80
+	//maxSlackPairs is the number of pairs described by a slack message, the others are only counted.
+	maxSlackPairs = 20
+)
+
+//Sink receives results of batch or runner comparisons, like a webhook or a chat channel. It must be safe for
+//concurrent use.
+type Sink interface {
+	Send(ctx context.Context, results []BatchResult) error
+}
+
+//Notify sends the results that differ or failed to the sinks, nothing is sent if all of them are equal. Sinks are
+//tried in order and the first error is returned after all of them are tried.
+func Notify(ctx context.Context, results []BatchResult, sinks ...Sink) error {
+	var findings []BatchResult
+	for _, result := range results {
+		if result.Err != nil || result.Result == nil || !result.Result.Equal() {
+			findings = append(findings, result)
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+	var first error
+	for _, sink := range sinks {
+		if err := sink.Send(ctx, findings); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+//WebhookSink posts the results as a json document like
+//{"results":[{"a_url":"...","b_url":"...","equal":false,"changes":[...],"error":"..."}]} to the url.
+type WebhookSink struct {
+	URL string
+	//Header is added to the requests, like an authorization header.
+	Header http.Header
+	//Client sends the requests, http.DefaultClient is used if it is nil.
+	Client *http.Client
+}
+
+//webhookResult is a result as posted by the webhook.
+type webhookResult struct {
+	AURL    string   `json:"a_url"`
+	BURL    string   `json:"b_url"`
+	Equal   bool     `json:"equal"`
+	Changes []Change `json:"changes"`
+	Error   string   `json:"error,omitempty"`
+}
+
+//Send posts the results.
+func (s *WebhookSink) Send(ctx context.Context, results []BatchResult) error {
+	payload := struct {
+		Results []webhookResult `json:"results"`
+	}{make([]webhookResult, len(results))}
+	for i, result := range results {
+		payload.Results[i] = newWebhookResult(result)
+	}
+	return postJSON(ctx, s.Client, s.URL, s.Header, &payload)
+}
+
+//newWebhookResult describes the result of the pair as posted by the webhook.
+func newWebhookResult(result BatchResult) webhookResult {
+	posted := webhookResult{AURL: result.Pair.AURL, BURL: result.Pair.BURL, Changes: []Change{}}
+	if result.Result != nil {
+		posted.Equal = result.Result.Equal()
+		if result.Result.Changes != nil {
+			posted.Changes = result.Result.Changes
+		}
+	}
+	if result.Err != nil {
+		posted.Error = result.Err.Error()
+	}
+	return posted
+}
+
+//SlackSink posts a readable summary of the results to the slack incoming webhook. Long values are truncated and
+//only the first changes of every pair are listed.
+type SlackSink struct {
+	WebhookURL string
+	//MaxChanges is the number of listed changes of a pair, 5 by default.
+	MaxChanges int
+	//Client sends the requests, http.DefaultClient is used if it is nil.
+	Client *http.Client
+}
+
+//Send posts the summary of the results.
+func (s *SlackSink) Send(ctx context.Context, results []BatchResult) error {
+	maxChanges := s.MaxChanges
+	if maxChanges <= 0 {
+		maxChanges = defaultSlackChanges
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "*%d compared pairs differ*\n", len(results))
+	for i, result := range results {
+		if i == maxSlackPairs {
+			fmt.Fprintf(&buf, "_and %d more pairs_\n", len(results)-i)
+			break
+		}
+		fmt.Fprintf(&buf, "\n%s vs %s\n", result.Pair.AURL, result.Pair.BURL)
+		if result.Err != nil {
+			fmt.Fprintf(&buf, "> error: %s\n", truncate(result.Err.Error(), maxSlackValue))
+		}
+		if result.Result == nil {
+			continue
+		}
+		changes := result.Result.Changes
+		for j, change := range changes {
+			if j == maxChanges {
+				fmt.Fprintf(&buf, "> _and %d more changes_\n", len(changes)-j)
+				break
+			}
+			fmt.Fprintf(&buf, "> `%s`\n", truncatedChange(change))
+		}
+	}
+	payload := struct {
+		Text string `json:"text"`
+	}{buf.String()}
+	return postJSON(ctx, s.Client, s.WebhookURL, nil, &payload)
+}
+
+//truncatedChange describes the change with the values truncated.
+func truncatedChange(change Change) string {
+	if change.Old != nil {
+		change.Old = truncate(fmt.Sprint(change.Old), maxSlackValue)
+	}
+	if change.New != nil {
+		change.New = truncate(fmt.Sprint(change.New), maxSlackValue)
+	}
+	return strings.Replace(describeChange(change), "`", "'", -1)
+}
+
+//truncate shortens the text to at most length runes marking the truncation by an ellipsis.
+func truncate(text string, length int) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	return string([]rune(text)[:length-1]) + "…"
+}
+
+//postJSON posts the payload and fails for responses other than 2xx.
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post %s: %s", url, resp.Status)
+	}
+	return nil
+}
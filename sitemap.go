@@ -0,0 +1,222 @@
+package comparator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/sync/errgroup"
+)
+
+//defaultMaxSitemaps is the number of sitemaps of a sitemap index followed unless configured otherwise.
+const defaultMaxSitemaps = 50
+
+//Crawl describes how the sitemaps of the sites are found and which of their pages are compared.
+type Crawl struct {
+	//Path is the path of the sitemap resolved against the base urls, /sitemap.xml by default.
+	Path string
+	//ComparePages compares the pages listed by both sitemaps, only their coverage is compared otherwise.
+	ComparePages bool
+	//Elements are the compared html elements of the pages, nil compares the responses according to their content
+	//type.
+	Elements []string
+	//MaxSitemaps bounds the followed sitemaps of sitemap indexes, 50 by default.
+	MaxSitemaps int
+}
+
+//SitemapResult is the result of the comparison of the sitemaps of two sites. The pages are identified by their
+//paths including their queries, so that the sites may be served by different hosts.
+type SitemapResult struct {
+	//OnlyA and OnlyB are the sorted paths of the pages listed only by the sitemap of the side.
+	OnlyA []string
+	OnlyB []string
+	//Common are the sorted paths of the pages listed by both sitemaps.
+	Common []string
+	//Pages are the comparisons of the common pages in their order, nil unless the pages are compared.
+	Pages []BatchResult
+}
+
+//Equal reports whether both sitemaps list the same pages and all compared pages are equal.
+func (r *SitemapResult) Equal() bool {
+	if len(r.OnlyA) > 0 || len(r.OnlyB) > 0 {
+		return false
+	}
+	for _, page := range r.Pages {
+		if page.Err != nil || !page.Result.Equal() {
+			return false
+		}
+	}
+	return true
+}
+
+//sitemapDocument is either a urlset or a sitemap index.
+type sitemapDocument struct {
+	XMLName  xml.Name
+	URLs     []sitemapLocation `xml:"url"`
+	Sitemaps []sitemapLocation `xml:"sitemap"`
+}
+
+type sitemapLocation struct {
+	Loc string `xml:"loc"`
+}
+
+//CompareSitemaps compares the sitemaps of the sites with the default options.
+func CompareSitemaps(ctx context.Context, aBase, bBase string, crawl Crawl) (*SitemapResult, error) {
+	return defaultComparator.CompareSitemaps(ctx, aBase, bBase, crawl)
+}
+
+//CompareSitemaps fetches the sitemaps of both sites, following sitemap indexes and gzipped sitemaps, and compares
+//the pages they list. The common pages are compared as a batch by the configured number of workers if the crawl
+//compares them, every page is fetched from the url listed by the sitemap of its side.
+func (c *Comparator) CompareSitemaps(ctx context.Context, aBase, bBase string, crawl Crawl) (*SitemapResult, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	path := crawl.Path
+	if path == "" {
+		path = "/sitemap.xml"
+	}
+	aPages, bPages, err := c.collectSitemaps(ctx, joinURL(aBase, path), joinURL(bBase, path), crawl)
+	if err != nil {
+		return nil, err
+	}
+	result := &SitemapResult{}
+	for page := range aPages {
+		if _, ok := bPages[page]; ok {
+			result.Common = append(result.Common, page)
+		} else {
+			result.OnlyA = append(result.OnlyA, page)
+		}
+	}
+	for page := range bPages {
+		if _, ok := aPages[page]; !ok {
+			result.OnlyB = append(result.OnlyB, page)
+		}
+	}
+	sort.Strings(result.Common)
+	sort.Strings(result.OnlyA)
+	sort.Strings(result.OnlyB)
+	if crawl.ComparePages {
+		pairs := make([]Pair, len(result.Common))
+		for i, page := range result.Common {
+			pairs[i] = Pair{AURL: aPages[page], BURL: bPages[page], Elements: crawl.Elements}
+		}
+		result.Pages = c.CompareBatch(ctx, pairs)
+	}
+	return result, nil
+}
+
+//collectSitemaps collects the pages of both sitemaps concurrently within the fetch timeout.
+func (c *Comparator) collectSitemaps(ctx context.Context, aURL, bURL string, crawl Crawl) (map[string]string,
+	map[string]string, error) {
+	if c.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.fetchTimeout)
+		defer cancel()
+	}
+	var aPages, bPages map[string]string
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		pages, err := c.collectSitemap(groupCtx, aURL, &c.aRequest, crawl)
+		if err != nil {
+			return &FetchError{SideA, aURL, err}
+		}
+		aPages = pages
+		return nil
+	})
+	group.Go(func() error {
+		pages, err := c.collectSitemap(groupCtx, bURL, &c.bRequest, crawl)
+		if err != nil {
+			return &FetchError{SideB, bURL, err}
+		}
+		bPages = pages
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return aPages, bPages, nil
+}
+
+//collectSitemap fetches the sitemap and the sitemaps of the index it is and returns the urls of their pages by
+//their paths.
+func (c *Comparator) collectSitemap(ctx context.Context, sitemapURL string, request *Request,
+	crawl Crawl) (map[string]string, error) {
+	maxSitemaps := crawl.MaxSitemaps
+	if maxSitemaps <= 0 {
+		maxSitemaps = defaultMaxSitemaps
+	}
+	pages := make(map[string]string)
+	pending := []string{sitemapURL}
+	seen := map[string]bool{sitemapURL: true}
+	for fetched := 0; len(pending) > 0; fetched++ {
+		if fetched >= maxSitemaps {
+			return nil, fmt.Errorf("more than %d sitemaps in the index %s", maxSitemaps, sitemapURL)
+		}
+		current := pending[0]
+		pending = pending[1:]
+		document, err := c.getSitemap(ctx, current, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, location := range document.Sitemaps {
+			if loc := strings.TrimSpace(location.Loc); loc != "" && !seen[loc] {
+				seen[loc] = true
+				pending = append(pending, loc)
+			}
+		}
+		for _, location := range document.URLs {
+			loc := strings.TrimSpace(location.Loc)
+			page, err := url.Parse(loc)
+			if err != nil || loc == "" {
+				return nil, fmt.Errorf("sitemap %s: invalid location %q", current, loc)
+			}
+			if page.Path == "" {
+				page.Path = "/"
+			}
+			pages[page.RequestURI()] = loc
+		}
+	}
+	return pages, nil
+}
+
+//getSitemap fetches and parses the sitemap, which may be gzipped even if it is not served gzip encoded.
+func (c *Comparator) getSitemap(ctx context.Context, sitemapURL string, request *Request) (*sitemapDocument, error) {
+	resp, err := c.get(ctx, sitemapURL, request)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("sitemap %s: %s", sitemapURL, resp.Status)
+	}
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("sitemap %s: %v", sitemapURL, err)
+		}
+		if body, err = ioutil.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("sitemap %s: %v", sitemapURL, err)
+		}
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+	var document sitemapDocument
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("sitemap %s: %v", sitemapURL, err)
+	}
+	if name := document.XMLName.Local; name != "urlset" && name != "sitemapindex" {
+		return nil, fmt.Errorf("sitemap %s: unexpected root element %s", sitemapURL, name)
+	}
+	return &document, nil
+}
@@ -0,0 +1,51 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+//sitemapIndexServer serves a sitemap index listing the given number of sitemaps of one page each and counts the
+//requested sitemap documents.
+func sitemapIndexServer(sitemaps int, requests *int32) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.URL.Path == "/sitemap.xml" {
+			fmt.Fprint(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for i := 0; i < sitemaps; i++ {
+				fmt.Fprintf(w, `<sitemap><loc>%s/sitemap-%d.xml</loc></sitemap>`, server.URL, i)
+			}
+			fmt.Fprint(w, `</sitemapindex>`)
+			return
+		}
+		fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>%s/page%s</loc></url>`+
+			`</urlset>`, server.URL, r.URL.Path)
+	}))
+	return server
+}
+
+func TestCompareSitemapsMaxSitemaps(t *testing.T) {
+	var requests int32
+	server := sitemapIndexServer(3, &requests)
+	defer server.Close()
+
+	if _, err := New().CompareSitemaps(context.Background(), server.URL, server.URL, Crawl{MaxSitemaps: 3}); err == nil {
+		t.Errorf("an index with 4 sitemap documents is followed with MaxSitemaps 3")
+	}
+	if fetched := atomic.LoadInt32(&requests); fetched > 6 {
+		t.Errorf("fetched %d sitemap documents for both sides, want at most 6", fetched)
+	}
+
+	result, err := New().CompareSitemaps(context.Background(), server.URL, server.URL, Crawl{MaxSitemaps: 4})
+	if err != nil {
+		t.Fatalf("CompareSitemaps returned error %v", err)
+	}
+	if len(result.Common) != 3 || !result.Equal() {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
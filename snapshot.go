@@ -0,0 +1,179 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"unicode/utf8"
+)
+
+//Snapshot is a saved response used as the baseline of later comparisons. The body is saved decoded.
+type Snapshot struct {
+	URL    string
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+//snapshotJSON is the saved form of the snapshot. Text bodies are saved as they are to keep the snapshot readable,
+//other bodies in base64.
+type snapshotJSON struct {
+	URL        string      `json:"url"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       *string     `json:"body,omitempty"`
+	BodyBase64 []byte      `json:"body_base64,omitempty"`
+}
+
+//MarshalJSON saves the snapshot.
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	saved := snapshotJSON{URL: s.URL, Status: s.Status, Header: s.Header}
+	if utf8.Valid(s.Body) {
+		body := string(s.Body)
+		saved.Body = &body
+	} else {
+		saved.BodyBase64 = s.Body
+	}
+	return json.Marshal(&saved)
+}
+
+//UnmarshalJSON loads the snapshot.
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	var saved snapshotJSON
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	*s = Snapshot{URL: saved.URL, Status: saved.Status, Header: saved.Header, Body: saved.BodyBase64}
+	if saved.Body != nil {
+		s.Body = []byte(*saved.Body)
+	}
+	return nil
+}
+
+//LoadSnapshot reads the snapshot saved to the file.
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+//Save writes the snapshot to the file.
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+//response recreates the saved response.
+func (s *Snapshot) response() *http.Response {
+	resp := &http.Response{
+		Status:        strconv.Itoa(s.Status) + " " + http.StatusText(s.Status),
+		StatusCode:    s.Status,
+		Header:        s.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(s.Body)),
+		ContentLength: int64(len(s.Body)),
+	}
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	if u, err := url.Parse(s.URL); err == nil {
+		resp.Request = &http.Request{Method: http.MethodGet, URL: u}
+	}
+	return resp
+}
+
+//RecordSnapshot fetches the url with the default options and saves the response to the file.
+func RecordSnapshot(ctx context.Context, url, path string) error {
+	return defaultComparator.RecordSnapshot(ctx, url, path)
+}
+
+//CompareSnapshotFile compares the live response of the url with the snapshot saved to the file using the default
+//options.
+func CompareSnapshotFile(ctx context.Context, path, url string, compareElements []string) (*Result, error) {
+	return defaultComparator.CompareSnapshotFile(ctx, path, url, compareElements)
+}
+
+//TakeSnapshot fetches the url as the a side and returns the response as a snapshot.
+func (c *Comparator) TakeSnapshot(ctx context.Context, url string) (*Snapshot, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.fetchTimeout)
+		defer cancel()
+	}
+	resp, err := c.get(ctx, url, &c.aRequest)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{URL: url, Status: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+//RecordSnapshot fetches the url as the a side and saves the response to the file.
+func (c *Comparator) RecordSnapshot(ctx context.Context, url, path string) error {
+	snapshot, err := c.TakeSnapshot(ctx, url)
+	if err != nil {
+		return err
+	}
+	return snapshot.Save(path)
+}
+
+//CompareSnapshotFile compares the live response of the url with the snapshot saved to the file.
+func (c *Comparator) CompareSnapshotFile(ctx context.Context, path, url string, compareElements []string) (*Result,
+	error) {
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.CompareSnapshot(ctx, snapshot, url, compareElements)
+}
+
+//CompareSnapshot compares the snapshot as the a side with the live response of the url fetched as the b side.
+//Changes are reported as for two live responses, fetch errors of the live response as status changes.
+func (c *Comparator) CompareSnapshot(ctx context.Context, snapshot *Snapshot, url string, compareElements []string) (
+	*Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.compareSnapshot(ctx, snapshot, url, &c.bRequest, compareElements)
+}
+
+//compareSnapshot compares the snapshot with the live response of the url fetched as described by the request.
+func (c *Comparator) compareSnapshot(ctx context.Context, snapshot *Snapshot, url string, request *Request,
+	compareElements []string) (*Result, error) {
+	fetchCtx := ctx
+	if c.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		fetchCtx, cancel = context.WithTimeout(ctx, c.fetchTimeout)
+		defer cancel()
+	}
+	resp, fetchErr := c.get(fetchCtx, url, request)
+	if err := ctx.Err(); err != nil {
+		closeBody(resp)
+		return nil, err
+	}
+	result, err := c.compareResponses(ctx, snapshot.response(), nil, resp, fetchErr, compareElements)
+	if err != nil {
+		return nil, err
+	}
+	c.postprocess(result)
+	result.fetchErrors = fetchErrors(snapshot.URL, nil, url, fetchErr)
+	return result, nil
+}
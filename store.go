@@ -0,0 +1,204 @@
+package comparator
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+//Run is a recorded run of comparisons, like a suite run by a scheduled job.
+type Run struct {
+	//ID is assigned by the store once the run is saved.
+	ID      int64       `json:"id"`
+	Name    string      `json:"name"`
+	Time    time.Time   `json:"time"`
+	Results []RunResult `json:"results"`
+}
+
+//RunResult is the recorded result of a compared pair.
+type RunResult struct {
+	AURL    string   `json:"a_url"`
+	BURL    string   `json:"b_url"`
+	Equal   bool     `json:"equal"`
+	Changes []Change `json:"changes"`
+	Error   string   `json:"error,omitempty"`
+}
+
+//Store records runs so that their history can be queried across processes, like by the bolt store of the
+//boltstore package. It must be safe for concurrent use.
+type Store interface {
+	//SaveRun records the run and assigns its ID.
+	SaveRun(ctx context.Context, run *Run) error
+	//Runs returns the runs made at or after the time ordered by their times.
+	Runs(ctx context.Context, since time.Time) ([]*Run, error)
+}
+
+//NewRun describes the results of the batch as a run made now.
+func NewRun(name string, results []BatchResult) *Run {
+	run := &Run{Name: name, Time: time.Now(), Results: make([]RunResult, len(results))}
+	for i, result := range results {
+		posted := newWebhookResult(result)
+		run.Results[i] = RunResult{posted.AURL, posted.BURL, posted.Equal, posted.Changes, posted.Error}
+	}
+	return run
+}
+
+//RecordSuite saves the results of the suite as a run named by the suite.
+func RecordSuite(ctx context.Context, store Store, result SuiteResult) (*Run, error) {
+	run := NewRun(result.Name, result.Results)
+	if err := store.SaveRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+//HistoryEntry is the result of a pair in one of the runs.
+type HistoryEntry struct {
+	RunID   int64
+	Time    time.Time
+	Equal   bool
+	Changes int
+	Error   string
+}
+
+//PairHistory is the history of the results of a pair ordered by the times of the runs.
+type PairHistory struct {
+	AURL    string
+	BURL    string
+	Entries []HistoryEntry
+}
+
+//DivergingSince returns the entry that started the streak of differing or failing results the pair ends with,
+//reporting whether the last result of the pair differs or fails at all.
+func (h *PairHistory) DivergingSince() (HistoryEntry, bool) {
+	var since HistoryEntry
+	diverging := false
+	for _, entry := range h.Entries {
+		switch {
+		case entry.Equal && entry.Error == "":
+			diverging = false
+		case !diverging:
+			since, diverging = entry, true
+		}
+	}
+	return since, diverging
+}
+
+//History returns the histories of the pairs of the runs made at or after the time whose a or b url has the path,
+//like /api/v2/users, or of all pairs if the path is empty. Pairs are identified by both urls and ordered by them.
+func History(ctx context.Context, store Store, path string, since time.Time) ([]*PairHistory, error) {
+	runs, err := store.Runs(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	histories := make(map[[2]string]*PairHistory)
+	for _, run := range runs {
+		for _, result := range run.Results {
+			if path != "" && urlPath(result.AURL) != path && urlPath(result.BURL) != path {
+				continue
+			}
+			key := [2]string{result.AURL, result.BURL}
+			history := histories[key]
+			if history == nil {
+				history = &PairHistory{AURL: result.AURL, BURL: result.BURL}
+				histories[key] = history
+			}
+			history.Entries = append(history.Entries, HistoryEntry{run.ID, run.Time, result.Equal,
+				len(result.Changes), result.Error})
+		}
+	}
+	sorted := make([]*PairHistory, 0, len(histories))
+	for _, history := range histories {
+		sorted = append(sorted, history)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].AURL != sorted[j].AURL {
+			return sorted[i].AURL < sorted[j].AURL
+		}
+		return sorted[i].BURL < sorted[j].BURL
+	})
+	return sorted, nil
+}
+
+//urlPath returns the path of the url, the url itself if it can't be parsed.
+func urlPath(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Path
+}
+
+//TrendPoint counts the results of a run.
+type TrendPoint struct {
+	RunID     int64
+	Name      string
+	Time      time.Time
+	Equal     int
+	Different int
+	Failed    int
+	Changes   int
+}
+
+//Trend counts the results of the runs made at or after the time, ordered by their times.
+func Trend(ctx context.Context, store Store, since time.Time) ([]TrendPoint, error) {
+	runs, err := store.Runs(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]TrendPoint, len(runs))
+	for i, run := range runs {
+		point := TrendPoint{RunID: run.ID, Name: run.Name, Time: run.Time}
+		for _, result := range run.Results {
+			switch {
+			case result.Error != "":
+				point.Failed++
+			case result.Equal:
+				point.Equal++
+			default:
+				point.Different++
+			}
+			point.Changes += len(result.Changes)
+		}
+		points[i] = point
+	}
+	return points, nil
+}
+
+//MemoryStore keeps the runs in memory, for tests and for processes that query only their own runs.
+type MemoryStore struct {
+	mu   sync.Mutex
+	runs []*Run
+}
+
+//NewMemoryStore creates an empty memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+//SaveRun records the run.
+func (s *MemoryStore) SaveRun(_ context.Context, run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.ID = int64(len(s.runs) + 1)
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+//Runs returns the runs made at or after the time.
+func (s *MemoryStore) Runs(_ context.Context, since time.Time) ([]*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var runs []*Run
+	for _, run := range s.runs {
+		if !run.Time.Before(since) {
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Time.Before(runs[j].Time)
+	})
+	return runs, nil
+}
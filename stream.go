@@ -0,0 +1,182 @@
+package comparator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	//streamWindowLines is the number of lines of every side diffed at once by streaming comparison.
+	streamWindowLines = 1000
+	//maxStreamLine is the length at which long lines are split by streaming comparison.
+	maxStreamLine = 64 * 1024
+)
+
+//readBodyLimited reads the body unless it is larger than the in-memory limit. The read prefix of a larger body is
+//returned along with true and the body is left open to be streamed, other bodies are closed.
+func (c *Comparator) readBodyLimited(resp *http.Response) ([]byte, bool, error) {
+	if c.maxInMemory <= 0 {
+		body, err := readBody(resp)
+		return body, false, err
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.maxInMemory+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, false, err
+	}
+	if int64(len(body)) > c.maxInMemory {
+		return body, true, nil
+	}
+	resp.Body.Close()
+	return body, false, nil
+}
+
+//remainingBody is a reader of the whole body, the part of a large body not read yet is read from the response.
+func remainingBody(prefix []byte, resp *http.Response, large bool) io.ReadCloser {
+	if !large {
+		return ioutil.NopCloser(bytes.NewReader(prefix))
+	}
+	return &decodingReader{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+}
+
+//lineStream reads lines of a body counting its size and hashing it after host substitutions.
+type lineStream struct {
+	reader *bufio.Reader
+	hash   hash.Hash
+	size   int64
+	eof    bool
+}
+
+func newLineStream(body io.ReadCloser) *lineStream {
+	return &lineStream{reader: bufio.NewReaderSize(body, maxStreamLine), hash: sha256.New()}
+}
+
+//fill reads lines until there are streamWindowLines of them or the body ends. Lines longer than maxStreamLine are
+//split.
+func (s *lineStream) fill(c *Comparator, lines []string) ([]string, error) {
+	for !s.eof && len(lines) < streamWindowLines {
+		line, err := s.reader.ReadSlice('\n')
+		if err == io.EOF {
+			s.eof = true
+		} else if err != nil && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+		if len(line) > 0 {
+			line = c.prepareBody(line)
+			s.hash.Write(line)
+			s.size += int64(len(line))
+			lines = append(lines, string(line))
+		}
+	}
+	return lines, nil
+}
+
+//compareStreams compares bodies too large to be kept in memory. Sizes and sha256 hashes are compared like for
+//binary bodies and the lines are diffed in windows of streamWindowLines. Unmatched lines at the end of a window
+//are carried to the next one, so insertions and deletions shorter than a window don't misalign the rest of the
+//bodies. Changes of the lines are located at "body/lines/<line>" by the first changed line of the a side.
+func (c *Comparator) compareStreams(ctx context.Context, aBody, bBody io.ReadCloser) (*Result, error) {
+	defer aBody.Close()
+	defer bBody.Close()
+	aStream := newLineStream(aBody)
+	bStream := newLineStream(bBody)
+	lines := &Result{}
+	var aLines, bLines []string
+	aLine := 1
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var err error
+		if aLines, err = aStream.fill(c, aLines); err != nil {
+			return nil, err
+		}
+		if bLines, err = bStream.fill(c, bLines); err != nil {
+			return nil, err
+		}
+		if len(aLines) == 0 && len(bLines) == 0 {
+			break
+		}
+		ops := diffLines(strings.Join(aLines, ""), strings.Join(bLines, ""))
+		cut := len(ops)
+		if !aStream.eof || !bStream.eof {
+			for cut > 0 && ops[cut-1].op != ' ' {
+				cut--
+			}
+			if cut == 0 {
+				cut = len(ops)
+			}
+		}
+		aLine = addLineChanges(lines, ops[:cut], aLine)
+		aLines, bLines = aLines[:0], bLines[:0]
+		for _, op := range ops[cut:] {
+			if op.op == '-' {
+				aLines = append(aLines, op.text)
+			} else {
+				bLines = append(bLines, op.text)
+			}
+		}
+	}
+	result := &Result{}
+	addHashChanges(result, aStream.size, bStream.size, hex.EncodeToString(aStream.hash.Sum(nil)),
+		hex.EncodeToString(bStream.hash.Sum(nil)))
+	result.merge(lines)
+	result.bodySize = aStream.size + bStream.size
+	return result, nil
+}
+
+//addHashChanges adds the changes of the sizes and hashes of the streamed bodies like for binary bodies.
+func addHashChanges(result *Result, aSize, bSize int64, aHash, bHash string) {
+	if aSize != bSize {
+		result.add(Change{"body/size", Modified, aSize, bSize},
+			Diff{fmt.Sprintf("Size: %d bytes", aSize), Delete}, Diff{fmt.Sprintf("Size: %d bytes", bSize), Insert})
+	}
+	if aHash != bHash {
+		result.add(Change{"body/sha256", Modified, aHash, bHash},
+			Diff{"SHA-256: " + aHash, Delete}, Diff{"SHA-256: " + bHash, Insert})
+	}
+}
+
+//addLineChanges adds a change for every run of changed lines and returns the number of the a side line following
+//the lines.
+func addLineChanges(result *Result, ops []lineOp, aLine int) int {
+	for i := 0; i < len(ops); {
+		if ops[i].op == ' ' {
+			aLine++
+			i++
+			continue
+		}
+		start := aLine
+		var deleted, inserted strings.Builder
+		for ; i < len(ops) && ops[i].op != ' '; i++ {
+			if ops[i].op == '-' {
+				deleted.WriteString(ops[i].text)
+				aLine++
+			} else {
+				inserted.WriteString(ops[i].text)
+			}
+		}
+		change := Change{Path: fmt.Sprintf("body/lines/%d", start),
+			Kind: changeKind(deleted.Len() > 0, inserted.Len() > 0)}
+		var diffs []Diff
+		if deleted.Len() > 0 {
+			change.Old = deleted.String()
+			diffs = append(diffs, Diff{deleted.String(), Delete})
+		}
+		if inserted.Len() > 0 {
+			change.New = inserted.String()
+			diffs = append(diffs, Diff{inserted.String(), Insert})
+		}
+		result.add(change, diffs...)
+	}
+	return aLine
+}
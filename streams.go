@@ -0,0 +1,205 @@
+package comparator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/sync/errgroup"
+)
+
+//StreamCapture limits capturing of messages of websocket and server-sent events streams and describes how the
+//captured messages are compared. Capture of a side stops when any of the limits is reached or the stream ends.
+type StreamCapture struct {
+	//Messages is the number of captured messages, zero for no limit.
+	Messages int
+	//Duration limits the capture time, zero for no limit.
+	Duration time.Duration
+	//JSON decodes messages as json documents so that they are compared structurally, messages that are not valid
+	//json are compared as strings.
+	JSON bool
+	//Unordered matches equal messages regardless of their order.
+	Unordered bool
+	//Send are the messages sent to websockets after connecting.
+	Send [][]byte
+}
+
+//CompareSSE captures server-sent events of both urls with the default options and compares them.
+func CompareSSE(ctx context.Context, aURL, bURL string, capture StreamCapture) (*Result, error) {
+	return defaultComparator.CompareSSE(ctx, aURL, bURL, capture)
+}
+
+//CompareWebSocket captures messages of both websockets with the default options and compares them.
+func CompareWebSocket(ctx context.Context, aURL, bURL string, capture StreamCapture) (*Result, error) {
+	return defaultComparator.CompareWebSocket(ctx, aURL, bURL, capture)
+}
+
+//CompareSSE captures data of server-sent events of both urls concurrently and compares them as sequences of
+//messages addressed by their position, like /3 or /3/price for json messages. Side requests apply.
+func (c *Comparator) CompareSSE(ctx context.Context, aURL, bURL string, capture StreamCapture) (*Result, error) {
+	return c.compareStreamMessages(ctx, aURL, bURL, capture, c.captureSSE)
+}
+
+//CompareWebSocket captures messages of both websockets concurrently and compares them as CompareSSE does. Headers,
+//cookies and authentication of the side requests apply to the handshakes.
+func (c *Comparator) CompareWebSocket(ctx context.Context, aURL, bURL string, capture StreamCapture) (*Result,
+	error) {
+	return c.compareStreamMessages(ctx, aURL, bURL, capture, c.captureWebSocket)
+}
+
+type captureFunc func(ctx context.Context, url string, request *Request, capture *StreamCapture) ([]string, error)
+
+func (c *Comparator) compareStreamMessages(ctx context.Context, aURL, bURL string, capture StreamCapture,
+	captureMessages captureFunc) (*Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	var aMessages, bMessages []string
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() (err error) {
+		aMessages, err = captureMessages(groupCtx, aURL, &c.aRequest, &capture)
+		return err
+	})
+	group.Go(func() (err error) {
+		bMessages, err = captureMessages(groupCtx, bURL, &c.bRequest, &capture)
+		return err
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	aValues := streamValues(aMessages, capture.JSON)
+	bValues := streamValues(bMessages, capture.JSON)
+	if capture.Unordered {
+		bValues = c.reorderByValue(aValues, bValues)
+	}
+	result, err := c.compareTrees(ctx, []interface{}{aValues}, []interface{}{bValues}, "", "")
+	if err != nil {
+		return nil, err
+	}
+	c.postprocess(result)
+	return result, nil
+}
+
+func streamValues(messages []string, decode bool) []interface{} {
+	values := make([]interface{}, len(messages))
+	for i, message := range messages {
+		values[i] = message
+		if decode {
+			var value interface{}
+			if err := json.Unmarshal([]byte(message), &value); err == nil {
+				values[i] = value
+			}
+		}
+	}
+	return values
+}
+
+//captureContext limits the context by the capture duration. Reaching the limit ends the capture without an error.
+func captureContext(ctx context.Context, capture *StreamCapture) (context.Context, context.CancelFunc) {
+	if capture.Duration > 0 {
+		return context.WithTimeout(ctx, capture.Duration)
+	}
+	return context.WithCancel(ctx)
+}
+
+//captureSSE reads data of the events of the stream. Multi-line data is joined with line breaks, comments and
+//events without data are skipped.
+func (c *Comparator) captureSSE(ctx context.Context, url string, request *Request, capture *StreamCapture) (
+	[]string, error) {
+	captureCtx, cancel := captureContext(ctx, capture)
+	defer cancel()
+	req, err := c.newRequest(captureCtx, url, request)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, captureErr(ctx, captureCtx, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sse %s: %s", url, resp.Status)
+	}
+	var messages []string
+	var data []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data != nil {
+				messages = append(messages, strings.Join(data, "\n"))
+				data = nil
+				if capture.Messages > 0 && len(messages) == capture.Messages {
+					return messages, nil
+				}
+			}
+			continue
+		}
+		field, value := line, ""
+		if i := strings.Index(line, ":"); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		if field == "data" {
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return messages, captureErr(ctx, captureCtx, err)
+	}
+	return messages, nil
+}
+
+//captureWebSocket sends the capture messages and reads messages of the websocket.
+func (c *Comparator) captureWebSocket(ctx context.Context, url string, request *Request,
+	capture *StreamCapture) ([]string, error) {
+	captureCtx, cancel := captureContext(ctx, capture)
+	defer cancel()
+	req, err := c.newRequest(captureCtx, url, request)
+	if err != nil {
+		return nil, err
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(captureCtx, url, req.Header)
+	if err != nil {
+		return nil, captureErr(ctx, captureCtx, err)
+	}
+	defer conn.Close()
+	go func() {
+		<-captureCtx.Done()
+		conn.Close()
+	}()
+	for _, message := range capture.Send {
+		if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			return nil, captureErr(ctx, captureCtx, err)
+		}
+	}
+	var messages []string
+	for capture.Messages == 0 || len(messages) < capture.Messages {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return messages, nil
+			}
+			return messages, captureErr(ctx, captureCtx, err)
+		}
+		messages = append(messages, string(message))
+	}
+	return messages, nil
+}
+
+//captureErr returns the error of the parent context if it is done. Errors caused by the end of the capture
+//duration are dropped.
+func captureErr(ctx, captureCtx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if captureCtx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+package comparator
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//Suite is a named set of comparisons with their options, it is usually loaded from a json or yaml file by
+//LoadSuites. Durations are written like "1.5s".
+type Suite struct {
+	Name string `json:"name" yaml:"name"`
+	//ABase and BBase resolve the pairs given by their paths.
+	ABase string      `json:"a_base" yaml:"a_base"`
+	BBase string      `json:"b_base" yaml:"b_base"`
+	Pairs []SuitePair `json:"pairs" yaml:"pairs"`
+	//Elements are the compared html elements of the pairs without their own ones.
+	Elements          []string              `json:"elements" yaml:"elements"`
+	Mode              Mode                  `json:"mode" yaml:"mode"`
+	Ignore            []string              `json:"ignore" yaml:"ignore"`
+	IgnoreHeaders     []string              `json:"ignore_headers" yaml:"ignore_headers"`
+	Headers           []string              `json:"headers" yaml:"headers"`
+	CompareHeaders    bool                  `json:"compare_headers" yaml:"compare_headers"`
+	CompareStatus     bool                  `json:"compare_status" yaml:"compare_status"`
+	CompareCookies    bool                  `json:"compare_cookies" yaml:"compare_cookies"`
+	CompareSecurity   bool                  `json:"compare_security" yaml:"compare_security"`
+	HostSubstitutions map[string]string     `json:"host_substitutions" yaml:"host_substitutions"`
+	NumericTolerance  float64               `json:"numeric_tolerance" yaml:"numeric_tolerance"`
+	UnorderedArrays   bool                  `json:"unordered_arrays" yaml:"unordered_arrays"`
+	Timeout           time.Duration         `json:"timeout" yaml:"timeout"`
+	LatencyBudget     time.Duration         `json:"latency_budget" yaml:"latency_budget"`
+	Performance       PerformanceThresholds `json:"performance" yaml:"performance"`
+	RateLimit         RateLimit             `json:"rate_limit" yaml:"rate_limit"`
+	Auth              struct {
+		A *AuthConfig `json:"a" yaml:"a"`
+		B *AuthConfig `json:"b" yaml:"b"`
+	} `json:"auth" yaml:"auth"`
+}
+
+//SuitePair is a compared pair of a suite given either by both urls or by the path resolved against the bases.
+type SuitePair struct {
+	A        string   `json:"a" yaml:"a"`
+	B        string   `json:"b" yaml:"b"`
+	Path     string   `json:"path" yaml:"path"`
+	Elements []string `json:"elements" yaml:"elements"`
+}
+
+//AuthConfig describes the authentication of a side. Type is one of basic, bearer, api_key and oauth2. Environment
+//variables like ${TOKEN} are expanded in the credentials, so that they don't have to be checked in.
+type AuthConfig struct {
+	Type         string   `json:"type" yaml:"type"`
+	Username     string   `json:"username" yaml:"username"`
+	Password     string   `json:"password" yaml:"password"`
+	Token        string   `json:"token" yaml:"token"`
+	Header       string   `json:"header" yaml:"header"`
+	Key          string   `json:"key" yaml:"key"`
+	TokenURL     string   `json:"token_url" yaml:"token_url"`
+	ClientID     string   `json:"client_id" yaml:"client_id"`
+	ClientSecret string   `json:"client_secret" yaml:"client_secret"`
+	Scopes       []string `json:"scopes" yaml:"scopes"`
+}
+
+//SuiteResult aggregates the comparisons of the pairs of a suite, pairs with a side that could not be fetched are
+//failed. Err is set if the options of the suite are invalid, no pairs are compared then.
+type SuiteResult struct {
+	Name      string
+	Results   []BatchResult
+	Equal     int
+	Different int
+	Failed    int
+	Err       error
+}
+
+//Passed reports whether all pairs of the suite are equal.
+func (r *SuiteResult) Passed() bool {
+	return r.Err == nil && r.Different == 0 && r.Failed == 0
+}
+
+//LoadSuites reads the suites of the json or yaml file.
+func LoadSuites(path string) ([]Suite, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	suites, err := ParseSuites(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return suites, nil
+}
+
+//ParseSuites parses the json or yaml document with the suites listed by its "suites" member. A document without
+//the member is parsed as a single suite.
+func ParseSuites(data []byte) ([]Suite, error) {
+	var document struct {
+		Suites []Suite `yaml:"suites"`
+	}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if document.Suites != nil {
+		return document.Suites, nil
+	}
+	var suite Suite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return nil, err
+	}
+	return []Suite{suite}, nil
+}
+
+//Options returns the options of the comparator described by the suite. The authentication of the sides replaces
+//only the authentication of their side requests.
+func (s *Suite) Options() ([]Option, error) {
+	var options []Option
+	if s.Mode != ModeAuto {
+		options = append(options, WithMode(s.Mode))
+	}
+	if len(s.Ignore) > 0 {
+		options = append(options, WithIgnoredPaths(s.Ignore...))
+	}
+	if len(s.IgnoreHeaders) > 0 {
+		options = append(options, WithIgnoredHeaders(s.IgnoreHeaders...))
+	}
+	if s.CompareHeaders || len(s.Headers) > 0 {
+		options = append(options, WithHeaderComparison(s.Headers...))
+	}
+	if s.CompareStatus {
+		options = append(options, WithStatusComparison())
+	}
+	if s.CompareCookies {
+		options = append(options, WithCookieComparison())
+	}
+	if s.CompareSecurity {
+		options = append(options, WithSecurityComparison())
+	}
+	if len(s.HostSubstitutions) > 0 {
+		options = append(options, WithHostSubstitutions(s.HostSubstitutions))
+	}
+	if s.NumericTolerance > 0 {
+		options = append(options, WithNumericTolerance(s.NumericTolerance))
+	}
+	if s.UnorderedArrays {
+		options = append(options, WithUnorderedArrays())
+	}
+	if s.Timeout > 0 {
+		options = append(options, WithFetchTimeout(s.Timeout))
+	}
+	if s.LatencyBudget > 0 {
+		options = append(options, WithLatencyBudget(s.LatencyBudget))
+	}
+	if s.Performance != (PerformanceThresholds{}) {
+		options = append(options, WithPerformanceThresholds(s.Performance))
+	}
+	if s.RateLimit != (RateLimit{}) {
+		options = append(options, WithRateLimit(s.RateLimit))
+	}
+	if s.Auth.A != nil || s.Auth.B != nil {
+		a, err := s.Auth.A.authenticator()
+		if err != nil {
+			return nil, err
+		}
+		b, err := s.Auth.B.authenticator()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, WithSideAuth(a, b))
+	}
+	return options, nil
+}
+
+//ComparedPairs resolves the pairs of the suite.
+func (s *Suite) ComparedPairs() []Pair {
+	pairs := make([]Pair, len(s.Pairs))
+	for i, pair := range s.Pairs {
+		pairs[i] = Pair{AURL: pair.A, BURL: pair.B, Elements: pair.Elements}
+		if pair.Path != "" {
+			if pair.A == "" {
+				pairs[i].AURL = joinURL(s.ABase, pair.Path)
+			}
+			if pair.B == "" {
+				pairs[i].BURL = joinURL(s.BBase, pair.Path)
+			}
+		}
+		if pairs[i].Elements == nil {
+			pairs[i].Elements = s.Elements
+		}
+	}
+	return pairs
+}
+
+//authenticator creates the authenticator described by the config, nil config doesn't authenticate.
+func (a *AuthConfig) authenticator() (Authenticator, error) {
+	if a == nil {
+		return nil, nil
+	}
+	switch a.Type {
+	case "basic":
+		return BasicAuth(os.ExpandEnv(a.Username), os.ExpandEnv(a.Password)), nil
+	case "bearer":
+		return BearerToken(os.ExpandEnv(a.Token)), nil
+	case "api_key":
+		return APIKey(a.Header, os.ExpandEnv(a.Key)), nil
+	case "oauth2":
+		return OAuth2ClientCredentials(a.TokenURL, os.ExpandEnv(a.ClientID), os.ExpandEnv(a.ClientSecret),
+			a.Scopes...), nil
+	}
+	return nil, fmt.Errorf("unknown auth type %q", a.Type)
+}
+
+//RunSuite compares the pairs of the suite as a batch by a comparator configured with the options followed by the
+//options of the suite, so that the suite overrides them.
+func RunSuite(ctx context.Context, suite Suite, options ...Option) SuiteResult {
+	result := SuiteResult{Name: suite.Name}
+	suiteOptions, err := suite.Options()
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	c := New(append(append([]Option{}, options...), suiteOptions...)...)
+	if c.err != nil {
+		result.Err = c.err
+		return result
+	}
+	result.Results = c.CompareBatch(ctx, suite.ComparedPairs())
+	for _, batchResult := range result.Results {
+		switch {
+		case batchResult.Err != nil || len(batchResult.Result.FetchErrors()) > 0:
+			result.Failed++
+		case batchResult.Result.Equal():
+			result.Equal++
+		default:
+			result.Different++
+		}
+	}
+	return result
+}
+
+//RunSuites runs the suites one by one as RunSuite does.
+func RunSuites(ctx context.Context, suites []Suite, options ...Option) []SuiteResult {
+	results := make([]SuiteResult, len(suites))
+	for i, suite := range suites {
+		results[i] = RunSuite(ctx, suite, options...)
+	}
+	return results
+}
@@ -0,0 +1,38 @@
+package comparator
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSuiteAuthKeepsSideRequests(t *testing.T) {
+	suite, err := ParseSuites([]byte(`
+auth:
+  a: {type: bearer, token: secret}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	options, err := suite[0].Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bAuth := BasicAuth("user", "password")
+	a := Request{Resolve: map[string]string{"api.example.com": "10.0.0.5"}, ServerName: "a.example.com",
+		Protocol: ProtocolHTTP2}
+	b := Request{Protocol: ProtocolHTTP1, Auth: bAuth}
+	c := New(append([]Option{WithSideRequests(a, b)}, options...)...)
+
+	if c.aRequest.Resolve["api.example.com"] != "10.0.0.5" || c.aRequest.ServerName != "a.example.com" ||
+		c.aRequest.Protocol != ProtocolHTTP2 {
+		t.Errorf("the suite auth dropped the a side request %+v", c.aRequest)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://api.example.com", nil)
+	if c.aRequest.Auth == nil || c.aRequest.Auth.Authenticate(req) != nil ||
+		req.Header.Get("Authorization") != "Bearer secret" {
+		t.Errorf("the suite auth is not set for the a side")
+	}
+	if c.bRequest.Protocol != ProtocolHTTP1 || c.bRequest.Auth == nil {
+		t.Errorf("the b side request without suite auth was changed %+v", c.bRequest)
+	}
+}
@@ -0,0 +1,145 @@
+package comparator
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//templateVariable matches the variables of url templates like /users/{id}.
+var templateVariable = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+//Variables are the values of the variables of url templates by their names.
+type Variables map[string]string
+
+//VariableSource generates the variables the templates are expanded with by passing them to yield one by one. It
+//stops once yield returns false. A source may be iterated several times.
+type VariableSource func(yield func(Variables) bool) error
+
+//VariableSlice generates the variables of the slice.
+func VariableSlice(variables []Variables) VariableSource {
+	return func(yield func(Variables) bool) error {
+		for _, set := range variables {
+			if !yield(set) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+//VariableRange generates the integers from the first to the last one, both included, as the variable.
+func VariableRange(name string, first, last int) VariableSource {
+	return func(yield func(Variables) bool) error {
+		for i := first; i <= last; i++ {
+			if !yield(Variables{name: strconv.Itoa(i)}) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+//VariableCSV generates the variables of the records of the csv document, the first record names the variables.
+//The document is read as the source is iterated, so it can only be iterated once.
+func VariableCSV(r io.Reader) VariableSource {
+	return func(yield func(Variables) bool) error {
+		reader := csv.NewReader(r)
+		names, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("variables: %v", err)
+		}
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("variables: %v", err)
+			}
+			set := make(Variables, len(names))
+			for i, name := range names {
+				set[strings.TrimSpace(name)] = record[i]
+			}
+			if !yield(set) {
+				return nil
+			}
+		}
+	}
+}
+
+//ExpandTemplate replaces the variables of the url template, like /users/{id}?expand={field}, by their values.
+//Values are escaped as path segments before the query and as query components in it. Variables without values
+//fail the expansion.
+func ExpandTemplate(template string, variables Variables) (string, error) {
+	query := strings.Index(template, "?")
+	var expanded strings.Builder
+	last := 0
+	for _, match := range templateVariable.FindAllStringSubmatchIndex(template, -1) {
+		name := template[match[2]:match[3]]
+		value, ok := variables[name]
+		if !ok {
+			return "", fmt.Errorf("template %s: no value of the variable %s", template, name)
+		}
+		expanded.WriteString(template[last:match[0]])
+		if query >= 0 && match[0] > query {
+			expanded.WriteString(url.QueryEscape(value))
+		} else {
+			expanded.WriteString(url.PathEscape(value))
+		}
+		last = match[1]
+	}
+	expanded.WriteString(template[last:])
+	return expanded.String(), nil
+}
+
+//ExpandPair expands the url templates of the pair with every variables of the source into the concrete pairs.
+func ExpandPair(template Pair, source VariableSource) ([]Pair, error) {
+	var pairs []Pair
+	var err error
+	sourceErr := source(func(variables Variables) bool {
+		pair := template
+		if pair.AURL, err = ExpandTemplate(template.AURL, variables); err != nil {
+			return false
+		}
+		if pair.BURL, err = ExpandTemplate(template.BURL, variables); err != nil {
+			return false
+		}
+		pairs = append(pairs, pair)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if sourceErr != nil {
+		return nil, sourceErr
+	}
+	return pairs, nil
+}
+
+//CompareSweep expands the pair of url templates and compares the concrete pairs with the default options.
+func CompareSweep(ctx context.Context, template Pair, source VariableSource) ([]BatchResult, error) {
+	return defaultComparator.CompareSweep(ctx, template, source)
+}
+
+//CompareSweep expands the pair of url templates, like /users/{id}, with every variables of the source and compares
+//the concrete pairs as a batch. The expansion fails before anything is compared if a template has a variable the
+//source doesn't provide.
+func (c *Comparator) CompareSweep(ctx context.Context, template Pair, source VariableSource) ([]BatchResult, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	pairs, err := ExpandPair(template, source)
+	if err != nil {
+		return nil, err
+	}
+	return c.CompareBatch(ctx, pairs), nil
+}
@@ -0,0 +1,183 @@
+package comparator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+//ANSI escape sequences of the terminal colors.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+//defaultTerminalWidth is the width of the side by side layout if the output is not a terminal.
+const defaultTerminalWidth = 120
+
+//ColorMode tells whether the terminal output is colored.
+type ColorMode int8
+
+//Color modes. ColorAuto colors the output if it is written to a terminal and the NO_COLOR environment variable is
+//not set.
+const (
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+//TerminalOptions configure the terminal rendering of results.
+type TerminalOptions struct {
+	Color ColorMode
+	//SideBySide renders the old and the new values in two columns with line numbers instead of a line by line diff.
+	SideBySide bool
+	//Width is the width of the side by side layout, the width of the terminal the output is written to by default.
+	Width int
+}
+
+//terminalWriter renders the changes of a result.
+type terminalWriter struct {
+	*bufio.Writer
+	color bool
+	width int
+}
+
+//WriteTerminal renders the changes for terminals, every change under a header with its path and kind followed by
+//the line by line diff of its values or their side by side view. Removed lines are red and added ones green if the
+//output is colored. Values are rendered as by WriteUnified.
+func (r *Result) WriteTerminal(w io.Writer, options TerminalOptions) error {
+	writer := terminalWriter{Writer: bufio.NewWriter(w), width: options.Width}
+	file, isFile := w.(*os.File)
+	tty := isFile && term.IsTerminal(int(file.Fd()))
+	switch options.Color {
+	case ColorAlways:
+		writer.color = true
+	case ColorAuto:
+		_, noColor := os.LookupEnv("NO_COLOR")
+		writer.color = tty && !noColor
+	}
+	if writer.width <= 0 {
+		writer.width = defaultTerminalWidth
+		if tty {
+			if width, _, err := term.GetSize(int(file.Fd())); err == nil && width > 0 {
+				writer.width = width
+			}
+		}
+	}
+	for _, change := range r.Changes {
+		aText, err := unifiedText(change.Old)
+		if err != nil {
+			return err
+		}
+		bText, err := unifiedText(change.New)
+		if err != nil {
+			return err
+		}
+		kindColor := ansiYellow
+		switch change.Kind {
+		case Added:
+			kindColor = ansiGreen
+		case Removed:
+			kindColor = ansiRed
+		}
+		path := change.Path
+		if path == "" {
+			path = "/"
+		}
+		writer.WriteString(writer.paint(ansiBold, path) + " " + writer.paint(kindColor, change.Kind.String()) + "\n")
+		ops := diffLines(aText, bText)
+		if options.SideBySide {
+			writer.writeSideBySide(ops)
+		} else {
+			writer.writeLines(ops)
+		}
+	}
+	return writer.Flush()
+}
+
+//paint colors the text if the output is colored.
+func (w *terminalWriter) paint(color, text string) string {
+	if !w.color || text == "" {
+		return text
+	}
+	return color + text + ansiReset
+}
+
+//writeLines writes the line by line diff, unchanged lines are dimmed.
+func (w *terminalWriter) writeLines(ops []lineOp) {
+	for _, op := range ops {
+		line := string(op.op) + " " + strings.TrimSuffix(op.text, "\n")
+		switch op.op {
+		case '-':
+			line = w.paint(ansiRed, line)
+		case '+':
+			line = w.paint(ansiGreen, line)
+		default:
+			line = w.paint(ansiDim, line)
+		}
+		w.WriteString(line + "\n")
+	}
+}
+
+//writeSideBySide writes the old lines on the left and the new ones on the right, both numbered. Removed and added
+//lines following each other are paired up, lines too long for their column are cut.
+func (w *terminalWriter) writeSideBySide(ops []lineOp) {
+	const separator = " │ "
+	numberWidth := len(fmt.Sprint(len(ops)))
+	column := (w.width - utf8.RuneCountInString(separator) - 2*(numberWidth+1)) / 2
+	if column < 10 {
+		column = 10
+	}
+	aLine, bLine := 0, 0
+	for i := 0; i < len(ops); {
+		if ops[i].op == ' ' {
+			aLine++
+			bLine++
+			text := w.cell(ansiDim, ops[i].text, column)
+			row := fmt.Sprintf("%*d %s%s%*d %s", numberWidth, aLine, text, separator, numberWidth, bLine, text)
+			w.WriteString(strings.TrimRight(row, " ") + "\n")
+			i++
+			continue
+		}
+		var removed, added []string
+		for ; i < len(ops) && ops[i].op == '-'; i++ {
+			removed = append(removed, ops[i].text)
+		}
+		for ; i < len(ops) && ops[i].op == '+'; i++ {
+			added = append(added, ops[i].text)
+		}
+		for row := 0; row < len(removed) || row < len(added); row++ {
+			left := strings.Repeat(" ", numberWidth+1+column)
+			if row < len(removed) {
+				aLine++
+				left = fmt.Sprintf("%*d %s", numberWidth, aLine, w.cell(ansiRed, removed[row], column))
+			}
+			right := ""
+			if row < len(added) {
+				bLine++
+				right = fmt.Sprintf("%*d %s", numberWidth, bLine, w.cell(ansiGreen, added[row], column))
+			}
+			w.WriteString(strings.TrimRight(left+separator+right, " ") + "\n")
+		}
+	}
+}
+
+//cell colors the line and pads it to the width of the column, or cuts it with an ellipsis if it is too long. Tabs
+//are expanded to four spaces.
+func (w *terminalWriter) cell(color, line string, width int) string {
+	line = strings.Replace(strings.TrimSuffix(line, "\n"), "\t", "    ", -1)
+	length := utf8.RuneCountInString(line)
+	if length > width {
+		line, length = string([]rune(line)[:width-1])+"…", width
+	}
+	return w.paint(color, line) + strings.Repeat(" ", width-length)
+}
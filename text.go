@@ -0,0 +1,174 @@
+package comparator
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+//Granularity is the unit of the text differences.
+type Granularity int8
+
+//Text diff granularities.
+const (
+	GranularityCharacter Granularity = iota
+	GranularityWord
+	GranularityLine
+)
+
+//compareTextBodies compares the bodies as plain text.
+func (c *Comparator) compareTextBodies(aBody, bBody []byte) *Result {
+	result := &Result{}
+	aText := c.normalize(string(aBody))
+	bText := c.normalize(string(bBody))
+	if aText != bText && !c.similarText("body", aText, bText) {
+		result.add(Change{"body", Modified, aText, bText}, c.compareText(aText, bText)...)
+	}
+	return result
+}
+
+//compareText diffs the strings at the configured granularity. Large strings are diffed in parallel chunks if
+//enabled.
+func (c *Comparator) compareText(aString, bString string) []Diff {
+	var result []Diff
+	var diffs []diffmatchpatch.Diff
+	if c.parallelDiffSize > 0 && (len(aString) > c.parallelDiffSize || len(bString) > c.parallelDiffSize) {
+		diffs = diffChunked(aString, bString, c.diffStrings)
+	} else {
+		diffs = c.diffStrings(aString, bString)
+	}
+	for i, element := range diffs {
+		if element.Type == diffmatchpatch.DiffInsert {
+			result = append(result, Diff{element.Text, Insert})
+		} else if element.Type == diffmatchpatch.DiffDelete {
+			result = append(result, Diff{element.Text, Delete})
+		} else if c.equalSegments {
+			result = append(result, c.equalContext(element.Text, i > 0, i < len(diffs)-1)...)
+		}
+	}
+	return result
+}
+
+//diffStrings diffs the strings as a whole at the configured granularity.
+func (c *Comparator) diffStrings(aString, bString string) []diffmatchpatch.Diff {
+	if c.granularity == GranularityCharacter {
+		return textDiffer.DiffCleanupSemantic(textDiffer.DiffMain(aString, bString, true))
+	}
+	return diffTokens(aString, bString, c.tokenizer())
+}
+
+//equalContext trims the unchanged text to the context lines next to the changes before and after it. The text
+//following a change keeps the rest of the changed line and the text preceding a change keeps its beginning on top
+//of the context lines.
+func (c *Comparator) equalContext(text string, afterChange, beforeChange bool) []Diff {
+	if c.contextLines < 0 {
+		return []Diff{{text, Equal}}
+	}
+	lines := splitLines(text)
+	var head, tail int
+	if afterChange {
+		head = c.contextLines + 1
+	}
+	if beforeChange {
+		tail = c.contextLines
+		if !strings.HasSuffix(text, "\n") {
+			tail++
+		}
+	}
+	if head+tail >= len(lines) {
+		if head == 0 && tail == 0 {
+			return nil
+		}
+		return []Diff{{text, Equal}}
+	}
+	var diffs []Diff
+	if head > 0 {
+		diffs = append(diffs, Diff{strings.Join(lines[:head], ""), Equal})
+	}
+	if tail > 0 {
+		diffs = append(diffs, Diff{strings.Join(lines[len(lines)-tail:], ""), Equal})
+	}
+	return diffs
+}
+
+//diffTokens diffs the strings by tokens like words or lines. Every distinct token is encoded as a rune, so that the
+//runes are diffed and decoded back to the tokens.
+func diffTokens(aString, bString string, tokenize func(string) []string) []diffmatchpatch.Diff {
+	var tokens []string
+	indexes := make(map[string]rune)
+	encode := func(text string) []rune {
+		var runes []rune
+		for _, token := range tokenize(text) {
+			r, ok := indexes[token]
+			if !ok {
+				r = tokenRune(len(tokens))
+				indexes[token] = r
+				tokens = append(tokens, token)
+			}
+			runes = append(runes, r)
+		}
+		return runes
+	}
+	aRunes := encode(aString)
+	bRunes := encode(bString)
+	diffs := textDiffer.DiffMainRunes(aRunes, bRunes, false)
+	for i, diff := range diffs {
+		var buf strings.Builder
+		for _, r := range diff.Text {
+			buf.WriteString(tokens[runeToken(r)])
+		}
+		diffs[i].Text = buf.String()
+	}
+	return diffs
+}
+
+func (c *Comparator) tokenizer() func(string) []string {
+	if c.granularity == GranularityLine {
+		return splitLines
+	}
+	return splitWords
+}
+
+//splitLines splits text into lines keeping the line breaks.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+//splitWords splits text into words and whitespace runs between them.
+func splitWords(text string) []string {
+	var tokens []string
+	start := 0
+	space := false
+	for i, r := range text {
+		if i > start && unicode.IsSpace(r) != space {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		space = unicode.IsSpace(r)
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+//tokenRune encodes the token index as a rune skipping surrogates that can't be represented in strings.
+func tokenRune(index int) rune {
+	r := rune(index)
+	if r >= 0xd800 {
+		r += 0x800
+	}
+	return r
+}
+
+func runeToken(r rune) int {
+	if r >= 0xe000 {
+		r -= 0x800
+	}
+	return int(r)
+}
@@ -0,0 +1,143 @@
+package comparator
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+//unifiedContext is the number of unchanged lines around the changed ones in unified diff hunks.
+const unifiedContext = 3
+
+//lineOp is a line of a line by line diff marked by ' ', '-' or '+'.
+type lineOp struct {
+	op   byte
+	text string
+}
+
+//WriteUnified renders the changes as a unified diff. Every change is a separate file named by its path without the
+//leading slash and with the a/ and b/ prefixes, added and removed values are compared with /dev/null. String values are
+//rendered as they are, other values as indented json.
+func (r *Result) WriteUnified(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	for _, change := range r.Changes {
+		name := strings.TrimPrefix(change.Path, "/")
+		if name == "" {
+			name = "."
+		}
+		aName, bName := "a/"+name, "b/"+name
+		if change.Kind == Added || change.Kind == Moved && change.Old == nil {
+			aName = "/dev/null"
+		}
+		if change.Kind == Removed || change.Kind == Moved && change.New == nil {
+			bName = "/dev/null"
+		}
+		aText, err := unifiedText(change.Old)
+		if err != nil {
+			return err
+		}
+		bText, err := unifiedText(change.New)
+		if err != nil {
+			return err
+		}
+		writeUnified(buf, aName, bName, aText, bText)
+	}
+	return buf.Flush()
+}
+
+func unifiedText(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	}
+	text, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(text) + "\n", nil
+}
+
+//writeUnified writes the file header and the hunks of the line by line diff of the texts.
+func writeUnified(w *bufio.Writer, aName, bName, aText, bText string) {
+	ops := diffLines(aText, bText)
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", aName, bName)
+	aLines := make([]int, len(ops)+1)
+	bLines := make([]int, len(ops)+1)
+	for i, op := range ops {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if op.op != '+' {
+			aLines[i+1]++
+		}
+		if op.op != '-' {
+			bLines[i+1]++
+		}
+	}
+	for i := 0; i < len(ops); i++ {
+		if ops[i].op == ' ' {
+			continue
+		}
+		start := i - unifiedContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for j := i; j < len(ops) && j <= end+2*unifiedContext; j++ {
+			if ops[j].op != ' ' {
+				end = j
+			}
+		}
+		end += unifiedContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+		fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(aLines[start], aLines[end]-aLines[start]),
+			hunkRange(bLines[start], bLines[end]-bLines[start]))
+		for _, op := range ops[start:end] {
+			w.WriteByte(op.op)
+			w.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				w.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end - 1
+	}
+}
+
+//hunkRange renders the range of lines starting after the line with the zero based index. Empty ranges are
+//located by the line before them.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+//diffLines diffs the texts line by line.
+func diffLines(aText, bText string) []lineOp {
+	diffs := diffTokens(aText, bText, splitLines)
+	var ops []lineOp
+	for _, diff := range diffs {
+		op := byte(' ')
+		switch diff.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+		for _, line := range strings.SplitAfter(diff.Text, "\n") {
+			if line != "" {
+				ops = append(ops, lineOp{op, line})
+			}
+		}
+	}
+	return ops
+}
@@ -0,0 +1,120 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+//compareXMLs compares xml documents structurally. Documents are converted into the json tree form, so the
+//changes are located by json pointers and json options like ignored paths and array keys apply to them.
+func (c *Comparator) compareXMLs(ctx context.Context, aBody, bBody []byte) (*Result, error) {
+	aValue, err := decodeXML(aBody)
+	if err != nil {
+		return nil, &ParseError{SideA, ModeXML, err}
+	}
+	bValue, err := decodeXML(bBody)
+	if err != nil {
+		return nil, &ParseError{SideB, ModeXML, err}
+	}
+	return c.compareTrees(ctx, []interface{}{aValue}, []interface{}{bValue}, "", "")
+}
+
+//xmlElement collects an element while the document is decoded.
+type xmlElement struct {
+	name     string
+	attrs    map[string]string
+	text     []string
+	names    []string
+	children map[string][]interface{}
+}
+
+//decodeXML converts the document into the json tree form. The root element becomes the only member of the
+//resulting object. Attributes are members prefixed with @ and the text is the #text member, an element with
+//neither attributes nor children is its text. Children are members named by their local names, repeated ones
+//are collected into arrays in the document order. Whitespace of the texts is collapsed, namespace declarations
+//are dropped and namespace prefixes are ignored.
+func decodeXML(body []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+	var stack []*xmlElement
+	root := &xmlElement{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				if attr.Name.Space != "xmlns" && attr.Name.Local != "xmlns" {
+					element.attrs[attr.Name.Local] = attr.Value
+				}
+			}
+			stack = append(stack, element)
+		case xml.EndElement:
+			element := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			parent := root
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			parent.addChild(element.name, element.value())
+		case xml.CharData:
+			if len(stack) > 0 {
+				if text := strings.Join(strings.Fields(string(t)), " "); text != "" {
+					element := stack[len(stack)-1]
+					element.text = append(element.text, text)
+				}
+			}
+		}
+	}
+	return root.value(), nil
+}
+
+func (e *xmlElement) addChild(name string, value interface{}) {
+	if e.children == nil {
+		e.children = make(map[string][]interface{})
+	}
+	if _, ok := e.children[name]; !ok {
+		e.names = append(e.names, name)
+	}
+	e.children[name] = append(e.children[name], value)
+}
+
+func (e *xmlElement) value() interface{} {
+	text := strings.Join(e.text, " ")
+	if len(e.attrs) == 0 && len(e.children) == 0 {
+		return text
+	}
+	object := make(map[string]interface{}, len(e.attrs)+len(e.children)+1)
+	names := make([]string, 0, len(e.attrs))
+	for name := range e.attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		object["@"+name] = e.attrs[name]
+	}
+	if text != "" {
+		object["#text"] = text
+	}
+	for _, name := range e.names {
+		children := e.children[name]
+		if len(children) == 1 {
+			object[name] = children[0]
+		} else {
+			object[name] = children
+		}
+	}
+	return object
+}
@@ -0,0 +1,75 @@
+package comparator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//compareYAMLs compares yaml documents semantically by converting them into the json tree form. Multiple
+//documents of a stream are compared positionally, like concatenated json values.
+func (c *Comparator) compareYAMLs(ctx context.Context, aBody, bBody []byte) (*Result, error) {
+	aValues, err := decodeYAMLStream(aBody)
+	if err != nil {
+		return nil, &ParseError{SideA, ModeYAML, err}
+	}
+	bValues, err := decodeYAMLStream(bBody)
+	if err != nil {
+		return nil, &ParseError{SideB, ModeYAML, err}
+	}
+	return c.compareTrees(ctx, aValues, bValues, "", "")
+}
+
+func decodeYAMLStream(body []byte) ([]interface{}, error) {
+	var values []interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(body))
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, yamlToJSON(value))
+	}
+}
+
+//yamlToJSON converts decoded yaml value into the json tree form: numbers become float64, mapping keys become
+//strings and timestamps become RFC 3339 strings.
+func yamlToJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = yamlToJSON(child)
+		}
+		return v
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			object[fmt.Sprint(key)] = yamlToJSON(child)
+		}
+		return object
+	case []interface{}:
+		for i, child := range v {
+			v[i] = yamlToJSON(child)
+		}
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return value
+}